@@ -30,12 +30,39 @@ type NextflowLaunchNextflow struct {
 	Home          string   `json:"home,omitempty"`
 	LogPath       string   `json:"logPath,omitempty"`
 	ScmSecretName string   `json:"scmSecretName,omitempty"`
+
+	// Plugins to enable, as id@version (or id, for the latest version)
+	Plugins []string `json:"plugins,omitempty"`
+	// Plugin cache on a mounted volume, where the plugins are downloaded
+	// before the driver starts
+	PluginsDir string `json:"pluginsDir,omitempty"`
+}
+
+// Credentials for a private pipeline repository, from a Secret of type
+// kubernetes.io/basic-auth (username and password or token) or
+// kubernetes.io/ssh-auth (ssh-privatekey and known_hosts)
+type NextflowLaunchCredentials struct {
+	// github, gitlab, bitbucket, gitea or generic (ssh only);
+	// inferred from the source if not set
+	Provider string `json:"provider,omitempty"`
+	// Server of a self-hosted provider, inferred from the source if not set
+	Server              string `json:"server,omitempty"`
+	BasicAuthSecretName string `json:"basicAuthSecretName,omitempty"`
+	SSHSecretName       string `json:"sshSecretName,omitempty"`
 }
 
 // Pipeline data
 type NextflowLaunchPipeline struct {
 	Source   string `json:"source,omitempty"`
 	Revision string `json:"revision,omitempty"`
+	// Inline main.nf, instead of a source
+	Script string `json:"script,omitempty"`
+	// Local pipeline directory (or script) on a mounted volume, instead of a source
+	Path      string `json:"path,omitempty"`
+	Prefetch  bool   `json:"prefetch,omitempty"`
+	AssetsDir string `json:"assetsDir,omitempty"`
+
+	Credentials *NextflowLaunchCredentials `json:"credentials,omitempty"`
 }
 
 // Main pod ("driver") configuration
@@ -44,26 +71,333 @@ type NextflowLaunchDriver struct {
 	Env         []corev1.EnvVar             `json:"env,omitempty"`
 	Labels      map[string]string           `json:"labels,omitempty"`
 	Resources   corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	// Keep the driver off spot/preemptible nodes
+	OnDemand bool `json:"onDemand,omitempty"`
+
+	// Partial pod template, strategically merged onto the driver pod.
+	// The driver container is referred to by the name "nextflow"
+	//+kubebuilder:validation:Schemaless
+	//+kubebuilder:validation:Type=object
+	//+kubebuilder:pruning:PreserveUnknownFields
+	PodTemplate *corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
+}
+
+// Nextflow secret backed by a key of a Kubernetes secret
+type NextflowLaunchSecret struct {
+	Name         string                   `json:"name"`
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
+	MountPath    string                   `json:"mountPath,omitempty"`
+}
+
+// Environment variable of the worker pods
+type NextflowLaunchPodEnv struct {
+	Name            string                       `json:"name"`
+	Value           string                       `json:"value,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	FieldPath       string                       `json:"fieldPath,omitempty"`
+}
+
+// Secret or ConfigMap (or a single key of it) mounted in the worker pods
+type NextflowLaunchPodMount struct {
+	Name      string `json:"name"`
+	Key       string `json:"key,omitempty"`
+	MountPath string `json:"mountPath"`
+}
+
+// Persistent volume claim mounted in the worker pods
+type NextflowLaunchPodVolumeClaim struct {
+	ClaimName string `json:"claimName"`
+	MountPath string `json:"mountPath"`
+	SubPath   string `json:"subPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// Worker pod options (typed equivalent of the pod directive)
+type NextflowLaunchPodOptions struct {
+	Env               []NextflowLaunchPodEnv         `json:"env,omitempty"`
+	Secrets           []NextflowLaunchPodMount       `json:"secrets,omitempty"`
+	ConfigMaps        []NextflowLaunchPodMount       `json:"configMaps,omitempty"`
+	VolumeClaims      []NextflowLaunchPodVolumeClaim `json:"volumeClaims,omitempty"`
+	Labels            map[string]string              `json:"labels,omitempty"`
+	Annotations       map[string]string              `json:"annotations,omitempty"`
+	NodeSelector      map[string]string              `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration            `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity               `json:"affinity,omitempty"`
+	SecurityContext   *corev1.PodSecurityContext     `json:"securityContext,omitempty"`
+	ImagePullSecret   string                         `json:"imagePullSecret,omitempty"`
+	PriorityClassName string                         `json:"priorityClassName,omitempty"`
+	RuntimeClassName  string                         `json:"runtimeClassName,omitempty"`
+}
+
+// Custom profile, rendered in the profiles scope of the generated config
+type NextflowLaunchProfile struct {
+	Name       string                    `json:"name"`
+	Params     map[string]string         `json:"params,omitempty"`
+	Env        map[string]string         `json:"env,omitempty"`
+	K8s        map[string]string         `json:"k8s,omitempty"`
+	Processes  []NextflowLaunchProcess   `json:"processes,omitempty"`
+	PodOptions *NextflowLaunchPodOptions `json:"podOptions,omitempty"`
+}
+
+// Volume mounted in the driver and in the workers
+type NextflowLaunchVolume struct {
+	MountPath  string `json:"mountPath"`
+	SubPath    string `json:"subPath,omitempty"`
+	ReadOnly   bool   `json:"readOnly,omitempty"`
+	DriverOnly bool   `json:"driverOnly,omitempty"`
+
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	ConfigMap             *corev1.LocalObjectReference              `json:"configMap,omitempty"`
+	Secret                *corev1.LocalObjectReference              `json:"secret,omitempty"`
+	NFS                   *corev1.NFSVolumeSource                   `json:"nfs,omitempty"`
+	CSI                   *corev1.CSIVolumeSource                   `json:"csi,omitempty"`
+	EmptyDir              *corev1.EmptyDirVolumeSource              `json:"emptyDir,omitempty"`
+}
+
+// Security settings of the driver and the workers
+type NextflowLaunchSecurity struct {
+	// Apply the "restricted" Pod Security Standard
+	Restricted             bool                   `json:"restricted,omitempty"`
+	RunAsNonRoot           *bool                  `json:"runAsNonRoot,omitempty"`
+	RunAsUser              *int64                 `json:"runAsUser,omitempty"`
+	RunAsGroup             *int64                 `json:"runAsGroup,omitempty"`
+	FSGroup                *int64                 `json:"fsGroup,omitempty"`
+	SeccompProfile         *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+	DropCapabilities       []corev1.Capability    `json:"dropCapabilities,omitempty"`
+	ReadOnlyRootFilesystem bool                   `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// Container run before or after the driver
+type NextflowLaunchHook struct {
+	Name      string                      `json:"name"`
+	Image     string                      `json:"image"`
+	Command   []string                    `json:"command,omitempty"`
+	Args      []string                    `json:"args,omitempty"`
+	Env       []corev1.EnvVar             `json:"env,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Hooks run around the driver: preRun as init containers of the driver pod,
+// the others in pods started after the driver has finished
+type NextflowLaunchHooks struct {
+	PreRun    []NextflowLaunchHook `json:"preRun,omitempty"`
+	OnSuccess []NextflowLaunchHook `json:"onSuccess,omitempty"`
+	OnFailure []NextflowLaunchHook `json:"onFailure,omitempty"`
+	Always    []NextflowLaunchHook `json:"always,omitempty"`
+}
+
+// Offline mode: the Nextflow runtime, the pipeline and the plugins are taken
+// from a pre-populated Nextflow home instead of being downloaded
+type NextflowLaunchOffline struct {
+	// Nextflow home on the storage volume or one of the additional volumes,
+	// holding framework/, assets/ and plugins/
+	Home string `json:"home,omitempty"`
+}
+
+// Service account of the driver and the workers
+type NextflowLaunchServiceAccount struct {
+	Name   string `json:"name,omitempty"`
+	Create bool   `json:"create,omitempty"`
+}
+
+// Source of the nextflow_schema.json file used to validate the params
+type NextflowLaunchParamsSchema struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	URL             string                       `json:"url,omitempty"`
+}
+
+// Process configuration, applied by a withName or withLabel selector
+type NextflowLaunchProcess struct {
+	WithName  string `json:"withName,omitempty"`
+	WithLabel string `json:"withLabel,omitempty"`
+	Cpus      *int32 `json:"cpus,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Disk      string `json:"disk,omitempty"`
+	Container string `json:"container,omitempty"`
+	//+kubebuilder:validation:Enum=terminate;finish;ignore;retry
+	ErrorStrategy string                    `json:"errorStrategy,omitempty"`
+	MaxRetries    *int32                    `json:"maxRetries,omitempty"`
+	Queue         string                    `json:"queue,omitempty"`
+	Ext           map[string]string         `json:"ext,omitempty"`
+	Pod           []map[string]string       `json:"pod,omitempty"`
+	PodOptions    *NextflowLaunchPodOptions `json:"podOptions,omitempty"`
+}
+
+// Raw Nextflow config stored in a ConfigMap or a Secret
+type NextflowLaunchConfigSource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+}
+
+// Container engine configuration (docker, singularity, apptainer, podman
+// and charliecloud scopes)
+type NextflowLaunchContainerEngine struct {
+	Enabled    bool   `json:"enabled,omitempty"`
+	Registry   string `json:"registry,omitempty"`
+	RunOptions string `json:"runOptions,omitempty"`
+	CacheDir   string `json:"cacheDir,omitempty"`
+	AutoMounts *bool  `json:"autoMounts,omitempty"`
+}
+
+// Wave containers configuration
+type NextflowLaunchWave struct {
+	Enabled         bool     `json:"enabled,omitempty"`
+	Endpoint        string   `json:"endpoint,omitempty"`
+	Strategy        []string `json:"strategy,omitempty"`
+	Freeze          bool     `json:"freeze,omitempty"`
+	BuildRepository string   `json:"buildRepository,omitempty"`
+	CacheRepository string   `json:"cacheRepository,omitempty"`
+}
+
+// Fusion file system configuration
+type NextflowLaunchFusion struct {
+	Enabled                  bool   `json:"enabled,omitempty"`
+	ExportStorageCredentials bool   `json:"exportStorageCredentials,omitempty"`
+	ContainerConfigURL       string `json:"containerConfigUrl,omitempty"`
+}
+
+// Execution report configuration
+type NextflowLaunchReport struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	File    string `json:"file,omitempty"`
+}
+
+// Execution reports: trace, report, timeline and DAG
+type NextflowLaunchReports struct {
+	Trace    *NextflowLaunchReport `json:"trace,omitempty"`
+	Report   *NextflowLaunchReport `json:"report,omitempty"`
+	Timeline *NextflowLaunchReport `json:"timeline,omitempty"`
+	Dag      *NextflowLaunchReport `json:"dag,omitempty"`
+}
+
+// Seqera Platform (Tower) configuration
+type NextflowLaunchTower struct {
+	Endpoint             string                   `json:"endpoint,omitempty"`
+	WorkspaceID          string                   `json:"workspaceId,omitempty"`
+	AccessTokenSecretRef corev1.SecretKeySelector `json:"accessTokenSecretRef"`
+}
+
+// Executor retry settings
+type NextflowLaunchExecutorRetry struct {
+	Delay      string `json:"delay,omitempty"`
+	MaxDelay   string `json:"maxDelay,omitempty"`
+	MaxAttempt *int32 `json:"maxAttempt,omitempty"`
+	Jitter     string `json:"jitter,omitempty"`
+}
+
+// Executor settings, governing the submission of workers
+type NextflowLaunchExecutor struct {
+	QueueSize       *int32                       `json:"queueSize,omitempty"`
+	PollInterval    string                       `json:"pollInterval,omitempty"`
+	SubmitRateLimit string                       `json:"submitRateLimit,omitempty"`
+	ExitReadTimeout string                       `json:"exitReadTimeout,omitempty"`
+	Retry           *NextflowLaunchExecutorRetry `json:"retry,omitempty"`
 }
 
 // NextflowLaunchSpec defines the desired state of NextflowLaunch
 type NextflowLaunchSpec struct {
-	Pipeline NextflowLaunchPipeline `json:"pipeline,omitempty"`
-	Nextflow NextflowLaunchNextflow `json:"nextflow,omitempty"`
-	Driver   NextflowLaunchDriver   `json:"driver,omitempty"`
-	Profile  string                 `json:"profile,omitempty"`
-	K8s      map[string]string      `json:"k8s,omitempty"`
-	Pod      []map[string]string    `json:"pod,omitempty"`
-	Params   map[string]string      `json:"params,omitempty"`
-	Env      map[string]string      `json:"env,omitempty"`
+	Pipeline           NextflowLaunchPipeline         `json:"pipeline,omitempty"`
+	Nextflow           NextflowLaunchNextflow         `json:"nextflow,omitempty"`
+	Driver             NextflowLaunchDriver           `json:"driver,omitempty"`
+	Profile            string                         `json:"profile,omitempty"`
+	Profiles           []string                       `json:"profiles,omitempty"`
+	ProfileDefinitions []NextflowLaunchProfile        `json:"profileDefinitions,omitempty"`
+	K8s                map[string]string              `json:"k8s,omitempty"`
+	Pod                []map[string]string            `json:"pod,omitempty"`
+	PodOptions         *NextflowLaunchPodOptions      `json:"podOptions,omitempty"`
+	Params             map[string]string              `json:"params,omitempty"`
+	Env                map[string]string              `json:"env,omitempty"`
+	Secrets            []NextflowLaunchSecret         `json:"secrets,omitempty"`
+	Processes          []NextflowLaunchProcess        `json:"processes,omitempty"`
+	ConfigFrom         []NextflowLaunchConfigSource   `json:"configFrom,omitempty"`
+	Docker             *NextflowLaunchContainerEngine `json:"docker,omitempty"`
+	Singularity        *NextflowLaunchContainerEngine `json:"singularity,omitempty"`
+	Apptainer          *NextflowLaunchContainerEngine `json:"apptainer,omitempty"`
+	Podman             *NextflowLaunchContainerEngine `json:"podman,omitempty"`
+	Charliecloud       *NextflowLaunchContainerEngine `json:"charliecloud,omitempty"`
+	Wave               *NextflowLaunchWave            `json:"wave,omitempty"`
+	Fusion             *NextflowLaunchFusion          `json:"fusion,omitempty"`
+	Reports            *NextflowLaunchReports         `json:"reports,omitempty"`
+	Tower              *NextflowLaunchTower           `json:"tower,omitempty"`
+	Executor           *NextflowLaunchExecutor        `json:"executor,omitempty"`
+	DryRun             bool                           `json:"dryRun,omitempty"`
+	ParamsSchema       *NextflowLaunchParamsSchema    `json:"paramsSchema,omitempty"`
+	Volumes            []NextflowLaunchVolume         `json:"volumes,omitempty"`
+	Hooks              *NextflowLaunchHooks           `json:"hooks,omitempty"`
+	Offline            *NextflowLaunchOffline         `json:"offline,omitempty"`
+	Security           *NextflowLaunchSecurity        `json:"security,omitempty"`
+	ServiceAccount     *NextflowLaunchServiceAccount  `json:"serviceAccount,omitempty"`
+}
+
+// Seqera Platform (Tower) run details
+type NextflowLaunchTowerStatus struct {
+	RunName    string `json:"runName,omitempty"`
+	WorkflowID string `json:"workflowId,omitempty"`
+	RunURL     string `json:"runUrl,omitempty"`
+}
+
+// Summary of the driver pod that would be created
+type NextflowLaunchPodSummary struct {
+	Image     string                      `json:"image,omitempty"`
+	Env       []string                    `json:"env,omitempty"`
+	Mounts    []string                    `json:"mounts,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Output of a dry run: what the operator would generate for the launch
+type NextflowLaunchDryRun struct {
+	ObservedGeneration int64                    `json:"observedGeneration,omitempty"`
+	Config             string                   `json:"config,omitempty"`
+	Command            []string                 `json:"command,omitempty"`
+	Pod                NextflowLaunchPodSummary `json:"pod,omitempty"`
+}
+
+// A single run of the driver pod
+type NextflowLaunchAttempt struct {
+	Pod        string      `json:"pod"`
+	ConfigMap  string      `json:"configMap"`
+	ConfigHash string      `json:"configHash"`
+	StartTime  metav1.Time `json:"startTime,omitempty"`
+}
+
+// Params validated against the pipeline schema, completed with its defaults
+type NextflowLaunchParamsStatus struct {
+	Effective map[string]string `json:"effective,omitempty"`
+	Errors    []string          `json:"errors,omitempty"`
+}
+
+// Outcome of a single hook
+type NextflowLaunchHookStatus struct {
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Pod      string `json:"pod"`
+	State    string `json:"state"`
+	ExitCode int32  `json:"exitCode,omitempty"`
 }
 
 // NextflowLaunchStatus defines the observed state of NextflowLaunch
 type NextflowLaunchStatus struct {
-	Stage     string                  `json:"stage,omitempty"`
-	MainPod   *corev1.ObjectReference `json:"mainpod,omitempty"`
-	ConfigMap *corev1.ObjectReference `json:"configmap,omitempty"`
-	Launched  bool                    `json:"launched,omitempty"`
+	Stage              string                      `json:"stage,omitempty"`
+	MainPod            *corev1.ObjectReference     `json:"mainpod,omitempty"`
+	ConfigMap          *corev1.ObjectReference     `json:"configmap,omitempty"`
+	Secret             *corev1.ObjectReference     `json:"secret,omitempty"`
+	Launched           bool                        `json:"launched,omitempty"`
+	Artifacts          map[string]string           `json:"artifacts,omitempty"`
+	Tower              *NextflowLaunchTowerStatus  `json:"tower,omitempty"`
+	DryRun             *NextflowLaunchDryRun       `json:"dryRun,omitempty"`
+	Attempts           []NextflowLaunchAttempt     `json:"attempts,omitempty"`
+	Params             *NextflowLaunchParamsStatus `json:"params,omitempty"`
+	MissingPermissions []string                    `json:"missingPermissions,omitempty"`
+	CommitID           string                      `json:"commitId,omitempty"`
+	Conditions         []metav1.Condition          `json:"conditions,omitempty"`
+	Hooks              []NextflowLaunchHookStatus  `json:"hooks,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Template of the launches generated by a launch set
+type NextflowLaunchTemplate struct {
+	Labels map[string]string  `json:"labels,omitempty"`
+	Spec   NextflowLaunchSpec `json:"spec,omitempty"`
+}
+
+// Samplesheet (CSV or TSV file with a header) stored in a ConfigMap;
+// every row yields a launch, with the columns passed as params
+type NextflowLaunchSetSamplesheet struct {
+	ConfigMapKeyRef corev1.ConfigMapKeySelector `json:"configMapKeyRef"`
+	Delimiter       string                      `json:"delimiter,omitempty"`
+}
+
+// NextflowLaunchSetSpec defines the desired state of NextflowLaunchSet
+type NextflowLaunchSetSpec struct {
+	Template     NextflowLaunchTemplate        `json:"template"`
+	Samplesheet  *NextflowLaunchSetSamplesheet `json:"samplesheet,omitempty"`
+	Matrix       map[string][]string           `json:"matrix,omitempty"`
+	Parallelism  int32                         `json:"parallelism,omitempty"`
+	NameTemplate string                        `json:"nameTemplate,omitempty"`
+	MaxRetries   int32                         `json:"maxRetries,omitempty"`
+}
+
+// State of a single launch generated by a launch set
+type NextflowLaunchSetChild struct {
+	Name    string `json:"name"`
+	Stage   string `json:"stage,omitempty"`
+	Retries int32  `json:"retries,omitempty"`
+}
+
+// NextflowLaunchSetStatus defines the observed state of NextflowLaunchSet
+type NextflowLaunchSetStatus struct {
+	Stage     string `json:"stage,omitempty"`
+	Total     int32  `json:"total,omitempty"`
+	Active    int32  `json:"active,omitempty"`
+	Succeeded int32  `json:"succeeded,omitempty"`
+	Failed    int32  `json:"failed,omitempty"`
+	// Children only rendered, in the DryRun stage
+	DryRun   int32                    `json:"dryRun,omitempty"`
+	Children []NextflowLaunchSetChild `json:"children,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Stage",type=string,JSONPath=`.status.stage`
+//+kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+//+kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+//+kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+
+// NextflowLaunchSet is the Schema for the nextflowlaunchsets API
+type NextflowLaunchSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NextflowLaunchSetSpec   `json:"spec,omitempty"`
+	Status NextflowLaunchSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NextflowLaunchSetList contains a list of NextflowLaunchSet
+type NextflowLaunchSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NextflowLaunchSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NextflowLaunchSet{}, &NextflowLaunchSetList{})
+}
@@ -23,6 +23,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -53,69 +54,907 @@ func (in *NextflowLaunch) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchAttempt) DeepCopyInto(out *NextflowLaunchAttempt) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchAttempt.
+func (in *NextflowLaunchAttempt) DeepCopy() *NextflowLaunchAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchConfigSource) DeepCopyInto(out *NextflowLaunchConfigSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchConfigSource.
+func (in *NextflowLaunchConfigSource) DeepCopy() *NextflowLaunchConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchContainerEngine) DeepCopyInto(out *NextflowLaunchContainerEngine) {
+	*out = *in
+	if in.AutoMounts != nil {
+		in, out := &in.AutoMounts, &out.AutoMounts
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchContainerEngine.
+func (in *NextflowLaunchContainerEngine) DeepCopy() *NextflowLaunchContainerEngine {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchContainerEngine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchCredentials) DeepCopyInto(out *NextflowLaunchCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchCredentials.
+func (in *NextflowLaunchCredentials) DeepCopy() *NextflowLaunchCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NextflowLaunchDriver) DeepCopyInto(out *NextflowLaunchDriver) {
 	*out = *in
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(v1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchDriver.
+func (in *NextflowLaunchDriver) DeepCopy() *NextflowLaunchDriver {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchDriver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchDryRun) DeepCopyInto(out *NextflowLaunchDryRun) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Pod.DeepCopyInto(&out.Pod)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchDryRun.
+func (in *NextflowLaunchDryRun) DeepCopy() *NextflowLaunchDryRun {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchDryRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchExecutor) DeepCopyInto(out *NextflowLaunchExecutor) {
+	*out = *in
+	if in.QueueSize != nil {
+		in, out := &in.QueueSize, &out.QueueSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(NextflowLaunchExecutorRetry)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchExecutor.
+func (in *NextflowLaunchExecutor) DeepCopy() *NextflowLaunchExecutor {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchExecutor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchExecutorRetry) DeepCopyInto(out *NextflowLaunchExecutorRetry) {
+	*out = *in
+	if in.MaxAttempt != nil {
+		in, out := &in.MaxAttempt, &out.MaxAttempt
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchExecutorRetry.
+func (in *NextflowLaunchExecutorRetry) DeepCopy() *NextflowLaunchExecutorRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchExecutorRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchFusion) DeepCopyInto(out *NextflowLaunchFusion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchFusion.
+func (in *NextflowLaunchFusion) DeepCopy() *NextflowLaunchFusion {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchFusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchHook) DeepCopyInto(out *NextflowLaunchHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchHook.
+func (in *NextflowLaunchHook) DeepCopy() *NextflowLaunchHook {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchHookStatus) DeepCopyInto(out *NextflowLaunchHookStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchHookStatus.
+func (in *NextflowLaunchHookStatus) DeepCopy() *NextflowLaunchHookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchHookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchHooks) DeepCopyInto(out *NextflowLaunchHooks) {
+	*out = *in
+	if in.PreRun != nil {
+		in, out := &in.PreRun, &out.PreRun
+		*out = make([]NextflowLaunchHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OnSuccess != nil {
+		in, out := &in.OnSuccess, &out.OnSuccess
+		*out = make([]NextflowLaunchHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OnFailure != nil {
+		in, out := &in.OnFailure, &out.OnFailure
+		*out = make([]NextflowLaunchHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Always != nil {
+		in, out := &in.Always, &out.Always
+		*out = make([]NextflowLaunchHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchHooks.
+func (in *NextflowLaunchHooks) DeepCopy() *NextflowLaunchHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchList) DeepCopyInto(out *NextflowLaunchList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NextflowLaunch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchList.
+func (in *NextflowLaunchList) DeepCopy() *NextflowLaunchList {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NextflowLaunchList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchNextflow) DeepCopyInto(out *NextflowLaunchNextflow) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchNextflow.
+func (in *NextflowLaunchNextflow) DeepCopy() *NextflowLaunchNextflow {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchNextflow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchOffline) DeepCopyInto(out *NextflowLaunchOffline) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchOffline.
+func (in *NextflowLaunchOffline) DeepCopy() *NextflowLaunchOffline {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchOffline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchParamsSchema) DeepCopyInto(out *NextflowLaunchParamsSchema) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchParamsSchema.
+func (in *NextflowLaunchParamsSchema) DeepCopy() *NextflowLaunchParamsSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchParamsSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchParamsStatus) DeepCopyInto(out *NextflowLaunchParamsStatus) {
+	*out = *in
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchParamsStatus.
+func (in *NextflowLaunchParamsStatus) DeepCopy() *NextflowLaunchParamsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchParamsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPipeline) DeepCopyInto(out *NextflowLaunchPipeline) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(NextflowLaunchCredentials)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPipeline.
+func (in *NextflowLaunchPipeline) DeepCopy() *NextflowLaunchPipeline {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPipeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPodEnv) DeepCopyInto(out *NextflowLaunchPodEnv) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPodEnv.
+func (in *NextflowLaunchPodEnv) DeepCopy() *NextflowLaunchPodEnv {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPodEnv)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPodMount) DeepCopyInto(out *NextflowLaunchPodMount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPodMount.
+func (in *NextflowLaunchPodMount) DeepCopy() *NextflowLaunchPodMount {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPodMount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPodOptions) DeepCopyInto(out *NextflowLaunchPodOptions) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]NextflowLaunchPodEnv, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]NextflowLaunchPodMount, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]NextflowLaunchPodMount, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeClaims != nil {
+		in, out := &in.VolumeClaims, &out.VolumeClaims
+		*out = make([]NextflowLaunchPodVolumeClaim, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPodOptions.
+func (in *NextflowLaunchPodOptions) DeepCopy() *NextflowLaunchPodOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPodOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPodSummary) DeepCopyInto(out *NextflowLaunchPodSummary) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPodSummary.
+func (in *NextflowLaunchPodSummary) DeepCopy() *NextflowLaunchPodSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPodSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchPodVolumeClaim) DeepCopyInto(out *NextflowLaunchPodVolumeClaim) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPodVolumeClaim.
+func (in *NextflowLaunchPodVolumeClaim) DeepCopy() *NextflowLaunchPodVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchPodVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchProcess) DeepCopyInto(out *NextflowLaunchProcess) {
+	*out = *in
+	if in.Cpus != nil {
+		in, out := &in.Cpus, &out.Cpus
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Ext != nil {
+		in, out := &in.Ext, &out.Ext
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.PodOptions != nil {
+		in, out := &in.PodOptions, &out.PodOptions
+		*out = new(NextflowLaunchPodOptions)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchProcess.
+func (in *NextflowLaunchProcess) DeepCopy() *NextflowLaunchProcess {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchProcess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchProfile) DeepCopyInto(out *NextflowLaunchProfile) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.K8s != nil {
+		in, out := &in.K8s, &out.K8s
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Processes != nil {
+		in, out := &in.Processes, &out.Processes
+		*out = make([]NextflowLaunchProcess, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodOptions != nil {
+		in, out := &in.PodOptions, &out.PodOptions
+		*out = new(NextflowLaunchPodOptions)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchProfile.
+func (in *NextflowLaunchProfile) DeepCopy() *NextflowLaunchProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchReport) DeepCopyInto(out *NextflowLaunchReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchReport.
+func (in *NextflowLaunchReport) DeepCopy() *NextflowLaunchReport {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchReports) DeepCopyInto(out *NextflowLaunchReports) {
+	*out = *in
+	if in.Trace != nil {
+		in, out := &in.Trace, &out.Trace
+		*out = new(NextflowLaunchReport)
+		**out = **in
+	}
+	if in.Report != nil {
+		in, out := &in.Report, &out.Report
+		*out = new(NextflowLaunchReport)
+		**out = **in
+	}
+	if in.Timeline != nil {
+		in, out := &in.Timeline, &out.Timeline
+		*out = new(NextflowLaunchReport)
+		**out = **in
+	}
+	if in.Dag != nil {
+		in, out := &in.Dag, &out.Dag
+		*out = new(NextflowLaunchReport)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchReports.
+func (in *NextflowLaunchReports) DeepCopy() *NextflowLaunchReports {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchReports)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchSecret) DeepCopyInto(out *NextflowLaunchSecret) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSecret.
+func (in *NextflowLaunchSecret) DeepCopy() *NextflowLaunchSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchSecurity) DeepCopyInto(out *NextflowLaunchSecurity) {
+	*out = *in
+	if in.RunAsNonRoot != nil {
+		in, out := &in.RunAsNonRoot, &out.RunAsNonRoot
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(v1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DropCapabilities != nil {
+		in, out := &in.DropCapabilities, &out.DropCapabilities
+		*out = make([]v1.Capability, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSecurity.
+func (in *NextflowLaunchSecurity) DeepCopy() *NextflowLaunchSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchServiceAccount) DeepCopyInto(out *NextflowLaunchServiceAccount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchServiceAccount.
+func (in *NextflowLaunchServiceAccount) DeepCopy() *NextflowLaunchServiceAccount {
+	if in == nil {
+		return nil
 	}
-	if in.Env != nil {
-		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out := new(NextflowLaunchServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchSet) DeepCopyInto(out *NextflowLaunchSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSet.
+func (in *NextflowLaunchSet) DeepCopy() *NextflowLaunchSet {
+	if in == nil {
+		return nil
 	}
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out := new(NextflowLaunchSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NextflowLaunchSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	in.Resources.DeepCopyInto(&out.Resources)
+	return nil
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchDriver.
-func (in *NextflowLaunchDriver) DeepCopy() *NextflowLaunchDriver {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchSetChild) DeepCopyInto(out *NextflowLaunchSetChild) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSetChild.
+func (in *NextflowLaunchSetChild) DeepCopy() *NextflowLaunchSetChild {
 	if in == nil {
 		return nil
 	}
-	out := new(NextflowLaunchDriver)
+	out := new(NextflowLaunchSetChild)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NextflowLaunchList) DeepCopyInto(out *NextflowLaunchList) {
+func (in *NextflowLaunchSetList) DeepCopyInto(out *NextflowLaunchSetList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]NextflowLaunch, len(*in))
+		*out = make([]NextflowLaunchSet, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchList.
-func (in *NextflowLaunchList) DeepCopy() *NextflowLaunchList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSetList.
+func (in *NextflowLaunchSetList) DeepCopy() *NextflowLaunchSetList {
 	if in == nil {
 		return nil
 	}
-	out := new(NextflowLaunchList)
+	out := new(NextflowLaunchSetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *NextflowLaunchList) DeepCopyObject() runtime.Object {
+func (in *NextflowLaunchSetList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -123,41 +962,73 @@ func (in *NextflowLaunchList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NextflowLaunchNextflow) DeepCopyInto(out *NextflowLaunchNextflow) {
+func (in *NextflowLaunchSetSamplesheet) DeepCopyInto(out *NextflowLaunchSetSamplesheet) {
 	*out = *in
-	if in.Command != nil {
-		in, out := &in.Command, &out.Command
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	in.ConfigMapKeyRef.DeepCopyInto(&out.ConfigMapKeyRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSetSamplesheet.
+func (in *NextflowLaunchSetSamplesheet) DeepCopy() *NextflowLaunchSetSamplesheet {
+	if in == nil {
+		return nil
 	}
-	if in.Args != nil {
-		in, out := &in.Args, &out.Args
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out := new(NextflowLaunchSetSamplesheet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchSetSpec) DeepCopyInto(out *NextflowLaunchSetSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Samplesheet != nil {
+		in, out := &in.Samplesheet, &out.Samplesheet
+		*out = new(NextflowLaunchSetSamplesheet)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchNextflow.
-func (in *NextflowLaunchNextflow) DeepCopy() *NextflowLaunchNextflow {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSetSpec.
+func (in *NextflowLaunchSetSpec) DeepCopy() *NextflowLaunchSetSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(NextflowLaunchNextflow)
+	out := new(NextflowLaunchSetSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NextflowLaunchPipeline) DeepCopyInto(out *NextflowLaunchPipeline) {
+func (in *NextflowLaunchSetStatus) DeepCopyInto(out *NextflowLaunchSetStatus) {
 	*out = *in
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]NextflowLaunchSetChild, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchPipeline.
-func (in *NextflowLaunchPipeline) DeepCopy() *NextflowLaunchPipeline {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSetStatus.
+func (in *NextflowLaunchSetStatus) DeepCopy() *NextflowLaunchSetStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NextflowLaunchPipeline)
+	out := new(NextflowLaunchSetStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -165,9 +1036,21 @@ func (in *NextflowLaunchPipeline) DeepCopy() *NextflowLaunchPipeline {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NextflowLaunchSpec) DeepCopyInto(out *NextflowLaunchSpec) {
 	*out = *in
-	out.Pipeline = in.Pipeline
+	in.Pipeline.DeepCopyInto(&out.Pipeline)
 	in.Nextflow.DeepCopyInto(&out.Nextflow)
 	in.Driver.DeepCopyInto(&out.Driver)
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProfileDefinitions != nil {
+		in, out := &in.ProfileDefinitions, &out.ProfileDefinitions
+		*out = make([]NextflowLaunchProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.K8s != nil {
 		in, out := &in.K8s, &out.K8s
 		*out = make(map[string]string, len(*in))
@@ -188,6 +1071,11 @@ func (in *NextflowLaunchSpec) DeepCopyInto(out *NextflowLaunchSpec) {
 			}
 		}
 	}
+	if in.PodOptions != nil {
+		in, out := &in.PodOptions, &out.PodOptions
+		*out = new(NextflowLaunchPodOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Params != nil {
 		in, out := &in.Params, &out.Params
 		*out = make(map[string]string, len(*in))
@@ -202,6 +1090,109 @@ func (in *NextflowLaunchSpec) DeepCopyInto(out *NextflowLaunchSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]NextflowLaunchSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Processes != nil {
+		in, out := &in.Processes, &out.Processes
+		*out = make([]NextflowLaunchProcess, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigFrom != nil {
+		in, out := &in.ConfigFrom, &out.ConfigFrom
+		*out = make([]NextflowLaunchConfigSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Docker != nil {
+		in, out := &in.Docker, &out.Docker
+		*out = new(NextflowLaunchContainerEngine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Singularity != nil {
+		in, out := &in.Singularity, &out.Singularity
+		*out = new(NextflowLaunchContainerEngine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Apptainer != nil {
+		in, out := &in.Apptainer, &out.Apptainer
+		*out = new(NextflowLaunchContainerEngine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Podman != nil {
+		in, out := &in.Podman, &out.Podman
+		*out = new(NextflowLaunchContainerEngine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Charliecloud != nil {
+		in, out := &in.Charliecloud, &out.Charliecloud
+		*out = new(NextflowLaunchContainerEngine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Wave != nil {
+		in, out := &in.Wave, &out.Wave
+		*out = new(NextflowLaunchWave)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fusion != nil {
+		in, out := &in.Fusion, &out.Fusion
+		*out = new(NextflowLaunchFusion)
+		**out = **in
+	}
+	if in.Reports != nil {
+		in, out := &in.Reports, &out.Reports
+		*out = new(NextflowLaunchReports)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tower != nil {
+		in, out := &in.Tower, &out.Tower
+		*out = new(NextflowLaunchTower)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Executor != nil {
+		in, out := &in.Executor, &out.Executor
+		*out = new(NextflowLaunchExecutor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ParamsSchema != nil {
+		in, out := &in.ParamsSchema, &out.ParamsSchema
+		*out = new(NextflowLaunchParamsSchema)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]NextflowLaunchVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(NextflowLaunchHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Offline != nil {
+		in, out := &in.Offline, &out.Offline
+		*out = new(NextflowLaunchOffline)
+		**out = **in
+	}
+	if in.Security != nil {
+		in, out := &in.Security, &out.Security
+		*out = new(NextflowLaunchSecurity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccount != nil {
+		in, out := &in.ServiceAccount, &out.ServiceAccount
+		*out = new(NextflowLaunchServiceAccount)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchSpec.
@@ -227,6 +1218,57 @@ func (in *NextflowLaunchStatus) DeepCopyInto(out *NextflowLaunchStatus) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tower != nil {
+		in, out := &in.Tower, &out.Tower
+		*out = new(NextflowLaunchTowerStatus)
+		**out = **in
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(NextflowLaunchDryRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]NextflowLaunchAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = new(NextflowLaunchParamsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MissingPermissions != nil {
+		in, out := &in.MissingPermissions, &out.MissingPermissions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]NextflowLaunchHookStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchStatus.
@@ -238,3 +1280,122 @@ func (in *NextflowLaunchStatus) DeepCopy() *NextflowLaunchStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchTemplate) DeepCopyInto(out *NextflowLaunchTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchTemplate.
+func (in *NextflowLaunchTemplate) DeepCopy() *NextflowLaunchTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchTower) DeepCopyInto(out *NextflowLaunchTower) {
+	*out = *in
+	in.AccessTokenSecretRef.DeepCopyInto(&out.AccessTokenSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchTower.
+func (in *NextflowLaunchTower) DeepCopy() *NextflowLaunchTower {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchTower)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchTowerStatus) DeepCopyInto(out *NextflowLaunchTowerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchTowerStatus.
+func (in *NextflowLaunchTowerStatus) DeepCopy() *NextflowLaunchTowerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchTowerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchVolume) DeepCopyInto(out *NextflowLaunchVolume) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.NFS != nil {
+		in, out := &in.NFS, &out.NFS
+		*out = new(v1.NFSVolumeSource)
+		**out = **in
+	}
+	if in.CSI != nil {
+		in, out := &in.CSI, &out.CSI
+		*out = new(v1.CSIVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(v1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchVolume.
+func (in *NextflowLaunchVolume) DeepCopy() *NextflowLaunchVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NextflowLaunchWave) DeepCopyInto(out *NextflowLaunchWave) {
+	*out = *in
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NextflowLaunchWave.
+func (in *NextflowLaunchWave) DeepCopy() *NextflowLaunchWave {
+	if in == nil {
+		return nil
+	}
+	out := new(NextflowLaunchWave)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// label marking the config maps generated for launches
+	configHashLabel = "batch.mnm.bio/config-hash"
+	// number of driver runs kept in the status of a launch
+	maxAttempts = 10
+)
+
+// Short content hash of a rendered Nextflow config
+func configHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Append a driver run to the status, dropping the oldest ones if necessary
+func recordAttempt(status *batchv1alpha1.NextflowLaunchStatus, pod corev1.Pod, configMap corev1.ConfigMap) {
+	status.Attempts = append(status.Attempts, batchv1alpha1.NextflowLaunchAttempt{
+		Pod:        pod.Name,
+		ConfigMap:  configMap.Name,
+		ConfigHash: configMap.Labels[configHashLabel],
+		StartTime:  metav1.Now(),
+	})
+	if len(status.Attempts) > maxAttempts {
+		status.Attempts = status.Attempts[len(status.Attempts)-maxAttempts:]
+	}
+}
+
+// Delete the config maps of a launch other than the current one
+func (r *NextflowLaunchReconciler) pruneConfigMaps(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch, current string) error {
+	var configMaps corev1.ConfigMapList
+	err := r.List(ctx, &configMaps, client.InNamespace(nfLaunch.Namespace), client.HasLabels{configHashLabel})
+	if err != nil {
+		return err
+	}
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if configMap.Name == current || !metav1.IsControlledBy(configMap, &nfLaunch) {
+			continue
+		}
+		err = r.Delete(ctx, configMap)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestRecordAttempt(t *testing.T) {
+	nfLaunch, _ := validateLaunch(testLaunch())
+	configMap, _ := makeNextflowConfig(nfLaunch)
+	var status batchv1alpha1.NextflowLaunchStatus
+	for i := 0; i < maxAttempts+2; i++ {
+		pod, _ := makeNextflowPod(nfLaunch, configMap.Name)
+		recordAttempt(&status, pod, configMap)
+	}
+	if len(status.Attempts) != maxAttempts {
+		t.Fatalf("%d attempts recorded", len(status.Attempts))
+	}
+	last := status.Attempts[maxAttempts-1]
+	if last.ConfigMap != configMap.Name || last.ConfigHash == "" || last.Pod == "" {
+		t.Errorf("incomplete attempt %+v", last)
+	}
+}
@@ -17,14 +17,15 @@ limitations under the License.
 package controllers
 
 import (
-	"bytes"
 	"errors"
-	"text/template"
+	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
 )
 
 const (
@@ -33,15 +34,22 @@ const (
 	defaultNextflowVersion = "22.06.0-edge"
 	defaultNextflowHome    = "/.nextflow"
 	configPath             = "/tmp/nextflow.config"
+	configFromPath         = "/tmp/nextflow-config.d"
+	secretsPath            = "/tmp/nextflow-secrets"
+	secretsStoreFile       = "store.json"
+	defaultSecretMountPath = "/var/run/secrets/nextflow"
+	reportsDir             = "reports"
 
 	statusRunning   = "Running"
 	statusSucceeded = "Succeeded"
 	statusFailed    = "Failed"
 	statusRelaunch  = "Relaunch"
+	statusDryRun    = "DryRun"
+	statusInvalid   = "Invalid"
 )
 
 // Construct a Pod object for Nextflow
-func makeNextflowPod(nfLaunch batchv1alpha1.NextflowLaunch, configMapName string) corev1.Pod {
+func makeNextflowPod(nfLaunch batchv1alpha1.NextflowLaunch, configMapName string) (corev1.Pod, error) {
 
 	spec := nfLaunch.Spec
 
@@ -56,7 +64,7 @@ func makeNextflowPod(nfLaunch batchv1alpha1.NextflowLaunch, configMapName string
 			Containers: []corev1.Container{{
 				Image:     spec.Nextflow.Image + ":" + spec.Nextflow.Version,
 				Command:   spec.Nextflow.Command,
-				Args:      spec.Nextflow.Args,
+				Args:      append([]string{}, spec.Nextflow.Args...),
 				Name:      nfLaunch.Name + "-" + generateHash(8),
 				Env:       spec.Driver.Env,
 				Resources: spec.Driver.Resources,
@@ -97,6 +105,10 @@ func makeNextflowPod(nfLaunch batchv1alpha1.NextflowLaunch, configMapName string
 		},
 	}
 
+	if spec.Pipeline.Script != "" {
+		mountScript(&pod)
+	}
+
 	// optionally attach a secret volume with scm data in it
 	if spec.Nextflow.ScmSecretName != "" {
 		pod.Spec.Containers[0].VolumeMounts = append(
@@ -120,77 +132,166 @@ func makeNextflowPod(nfLaunch batchv1alpha1.NextflowLaunch, configMapName string
 		)
 	}
 
-	return pod
+	// pass the Seqera Platform token and a known run name
+	if spec.Tower != nil {
+		pod.Spec.Containers[0].Env = append(
+			pod.Spec.Containers[0].Env,
+			corev1.EnvVar{
+				Name: "TOWER_ACCESS_TOKEN",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: spec.Tower.AccessTokenSecretRef.DeepCopy(),
+				},
+			},
+		)
+		if nfLaunch.Status.Tower != nil && nfLaunch.Status.Tower.RunName != "" {
+			// expanded by Kubernetes in the generated command
+			pod.Spec.Containers[0].Env = append(
+				pod.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: towerRunNameEnv, Value: nfLaunch.Status.Tower.RunName},
+			)
+		}
+	}
+
+	// attach the included config files
+	for i, source := range spec.ConfigFrom {
+		volume := corev1.Volume{Name: fmt.Sprintf("nextflow-config-from-%d", i)}
+		mount := corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: configFromFile(i),
+			ReadOnly:  true,
+		}
+		if source.ConfigMapKeyRef != nil {
+			mount.SubPath = source.ConfigMapKeyRef.Key
+			volume.VolumeSource.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: source.ConfigMapKeyRef.LocalObjectReference,
+			}
+		} else {
+			mount.SubPath = source.SecretKeyRef.Key
+			volume.VolumeSource.Secret = &corev1.SecretVolumeSource{
+				SecretName: source.SecretKeyRef.Name,
+			}
+		}
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, mount)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+	}
+
+	attachVolumes(&pod, spec.Volumes)
+	placeDriver(&pod, spec.Driver)
+	secureDriver(&pod, spec)
+	// after the security settings, which the init containers share
+	if credentials := spec.Pipeline.Credentials; credentials != nil && credentials.SSHSecretName != "" {
+		mountSSHKey(&pod, spec)
+	}
+	// optionally attach the Nextflow secrets store
+	if len(spec.Secrets) > 0 {
+		mountSecretsStore(&pod, nfLaunch)
+	}
+
+	if spec.ServiceAccount != nil {
+		pod.Spec.ServiceAccountName = spec.ServiceAccount.Name
+	}
+	if spec.Pipeline.Prefetch {
+		addPrefetch(&pod, spec)
+	}
+	if spec.Offline != nil {
+		addOfflineCheck(&pod, spec)
+	}
+	if spec.Nextflow.PluginsDir != "" {
+		addPluginsDownload(&pod, spec)
+	}
+	addPreRunHooks(&pod, nfLaunch)
+
+	// the user-provided template is merged last
+	err := applyPodTemplate(&pod, spec.Driver.PodTemplate)
+	if err != nil {
+		return corev1.Pod{}, err
+	}
+
+	return pod, nil
+}
+
+// Path of the i-th included config file in the driver pod
+func configFromFile(i int) string {
+	return fmt.Sprintf("%s/%02d.config", configFromPath, i)
 }
 
 // Construct a Nextflow config file as a ConfigMap
-func makeNextflowConfig(nfLaunch batchv1alpha1.NextflowLaunch) corev1.ConfigMap {
-
-	configTemplate, _ := template.New("config").
-		Funcs(template.FuncMap{
-			"stringsOrMap": stringsOrMap,
-			"escape":       escape,
-			"groovyValue":  groovyValue,
-		}).
-		Parse(`
-    		process {
-    		   executor = 'k8s'
-    		   {{ if .Pod -}}
-    		   pod = [
-    		   {{ range $opt := .Pod -}}
-    		   [
-    		   {{- stringsOrMap $opt -}}
-    		   ],
-    		   {{ end }}
-    		   ]
-    		   {{- end }}
-    		}
-    		{{ if .K8s -}}
-    		k8s {
-    		   {{- range $par, $value := .K8s }}
-    		   {{ escape $par }} = {{ groovyValue $value }}
-    		   {{- end }}
-    		}
-    		{{- end }}
-    		{{ if .Params -}}
-    		params {
-    		   {{- range $par, $value := .Params }}
-    		   {{ escape $par }} = {{ groovyValue $value }}
-    		   {{- end }}
-    		}
-    		{{- end }}
-    		{{ if .Env -}}
-    		env {
-    		   {{- range $par, $value := .Env }}
-    		   {{ escape $par }} = '{{ escape $value }}'
-    		   {{- end }}
-    		}
-    		{{- end }}`)
-
-	type Options struct {
-		K8s    map[string]string
-		Params map[string]string
-		Env    map[string]string
-		Pod    []map[string]string
-	}
-	values := Options{
-		K8s:    nfLaunch.Spec.K8s,
-		Params: nfLaunch.Spec.Params,
-		Env:    nfLaunch.Spec.Env,
-		Pod:    nfLaunch.Spec.Pod,
-	}
-	var config bytes.Buffer
-	configTemplate.Execute(&config, values)
-
-	return corev1.ConfigMap{
+func makeNextflowConfig(nfLaunch batchv1alpha1.NextflowLaunch) (corev1.ConfigMap, error) {
+
+	spec := nfLaunch.Spec
+	config := groovy.NewBlock()
+
+	process := config.Scope("process")
+	process.Set("executor", groovy.String("k8s"))
+	typed := spec.PodOptions.DeepCopy()
+	if typed == nil {
+		typed = &batchv1alpha1.NextflowLaunchPodOptions{}
+	}
+	typed.Secrets = append(typed.Secrets, secretPodMounts(spec.Secrets)...)
+	if spec.Security != nil && typed.SecurityContext == nil {
+		typed.SecurityContext = podSecurityContext(spec.Security)
+	}
+	pod, err := workerPodOptions(spec.Pod, typed)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	volumes, err := volumePodOptions(spec.Volumes)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	pod = append(pod, volumes...)
+	if len(pod) > 0 {
+		process.Set("pod", pod)
+	}
+	if err := configureProcesses(process, spec.Processes, pod); err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	if len(spec.K8s) > 0 {
+		config.Scope("k8s").SetAll(groovyValues(spec.K8s))
+	}
+	if len(spec.Params) > 0 {
+		config.Scope("params").SetAll(groovyValues(spec.Params))
+	}
+	if len(spec.Env) > 0 {
+		config.Scope("env").SetAll(groovyStrings(spec.Env))
+	}
+	configureContainers(config, spec)
+	configureReports(config, spec)
+	configureTower(config, spec)
+	configureExecutor(config, spec.Executor)
+	if err := configureProfiles(config, spec.ProfileDefinitions, pod); err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	configurePlugins(config, spec.Nextflow.Plugins)
+
+	// raw config is included last, so that it can override the settings above,
+	// except for the executor settings limited by the operator
+	for i := range spec.ConfigFrom {
+		config.Include(configFromFile(i))
+	}
+	enforceExecutor(config, spec.Executor)
+
+	text, err := config.Render()
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+
+	// named after the content, so that an unchanged config is reused
+	hash := configHash(text + spec.Pipeline.Script)
+	configMap := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      nfLaunch.Name + "-nextflow-config-" + generateHash(8),
+			Name:      nfLaunch.Name + "-nextflow-config-" + hash,
 			Namespace: nfLaunch.Namespace,
+			Labels:    map[string]string{configHashLabel: hash},
 		},
 		Data: map[string]string{
-			"nextflow.config": config.String(),
+			"nextflow.config": text,
 		},
 	}
+	if spec.Pipeline.Script != "" {
+		configMap.Data[scriptFile] = spec.Pipeline.Script
+	}
+	return configMap, nil
 }
 
 // Validate launch definition, return an error or nil
@@ -199,12 +300,54 @@ func validateLaunch(nfLaunch batchv1alpha1.NextflowLaunch) (batchv1alpha1.Nextfl
 	spec := nfLaunch.Spec
 
 	// validation
-	if spec.Pipeline.Source == "" {
-		return nfLaunch, errors.New("spec.pipeline.source is required")
-	}
 	if keyIsEmpty(spec.K8s, "storageClaimName") {
 		return nfLaunch, errors.New("spec.k8s.storageClaimName is required")
 	}
+	err := validateSecrets(spec.Secrets)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validatePodOptions("spec.podOptions", spec.PodOptions)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateProcesses("spec.processes", spec.Processes)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateProfiles(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateContainers(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateTower(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateServiceAccount(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateParamsSchema(spec.ParamsSchema)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateExecutor(spec.Executor)
+	if err != nil {
+		return nfLaunch, err
+	}
+	for i, source := range spec.ConfigFrom {
+		if (source.ConfigMapKeyRef == nil) == (source.SecretKeyRef == nil) {
+			return nfLaunch, fmt.Errorf("spec.configFrom[%d] requires exactly one of configMapKeyRef and secretKeyRef", i)
+		}
+		if (source.ConfigMapKeyRef != nil && (source.ConfigMapKeyRef.Name == "" || source.ConfigMapKeyRef.Key == "")) ||
+			(source.SecretKeyRef != nil && (source.SecretKeyRef.Name == "" || source.SecretKeyRef.Key == "")) {
+			return nfLaunch, fmt.Errorf("spec.configFrom[%d] requires both name and key", i)
+		}
+	}
 
 	// defaults for the essential settings
 	if keyIsEmpty(spec.K8s, "storageMountPath") {
@@ -216,17 +359,69 @@ func validateLaunch(nfLaunch batchv1alpha1.NextflowLaunch) (batchv1alpha1.Nextfl
 	if keyIsEmpty(spec.K8s, "workDir") {
 		spec.K8s["workDir"] = spec.K8s["launchDir"] + "/work"
 	}
+	err = validateVolumes(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validatePipeline(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validatePrefetch(spec.Pipeline)
+	if err != nil {
+		return nfLaunch, err
+	}
+	spec.Pipeline.Credentials, err = validateCredentials(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	if spec.Pipeline.Credentials != nil && spec.Pipeline.Credentials.BasicAuthSecretName != "" {
+		// the generated SCM file is mounted like a user-provided one
+		spec.Nextflow.ScmSecretName = scmSecretName(nfLaunch)
+	}
+	err = validateOffline(&spec)
+	if err != nil {
+		return nfLaunch, err
+	}
+	err = validateHooks(spec.Hooks)
+	if err != nil {
+		return nfLaunch, err
+	}
+	if spec.Pipeline.Prefetch && spec.Pipeline.AssetsDir == "" {
+		spec.Pipeline.AssetsDir = spec.K8s["storageMountPath"] + "/.nextflow-assets"
+	}
+	spec.Security, err = defaultSecurity(spec.Security)
+	if err != nil {
+		return nfLaunch, err
+	}
+	if spec.ServiceAccount != nil {
+		spec.ServiceAccount = spec.ServiceAccount.DeepCopy()
+		if spec.ServiceAccount.Name == "" {
+			spec.ServiceAccount.Name = serviceAccountName(nfLaunch)
+		}
+		if keyIsEmpty(spec.K8s, "serviceAccount") {
+			spec.K8s["serviceAccount"] = spec.ServiceAccount.Name
+		} else if spec.K8s["serviceAccount"] != spec.ServiceAccount.Name {
+			return nfLaunch, errors.New("spec.k8s.serviceAccount differs from spec.serviceAccount.name")
+		}
+	}
+	spec.Reports = spec.Reports.DeepCopy()
+	defaultReportFiles(spec)
 	if spec.Nextflow.Image == "" {
 		spec.Nextflow.Image = defaultNextflowImage
 	}
 	if spec.Nextflow.Version == "" {
 		spec.Nextflow.Version = defaultNextflowVersion
 	}
+	err = validatePlugins(spec)
+	if err != nil {
+		return nfLaunch, err
+	}
 	profileArg := ""
 	profileName := ""
-	if spec.Profile != "" {
+	if profiles := profileList(spec); len(profiles) > 0 {
 		profileArg = "-profile"
-		profileName = escape(spec.Profile)
+		profileName = escape(strings.Join(profiles, ","))
 	}
 	revisionArg := ""
 	revisionName := ""
@@ -240,6 +435,12 @@ func validateLaunch(nfLaunch batchv1alpha1.NextflowLaunch) (batchv1alpha1.Nextfl
 		logArg = "-log"
 		logName = escape(spec.Nextflow.LogPath)
 	}
+	nameArg := ""
+	nameValue := ""
+	if spec.Tower != nil {
+		nameArg = "-name"
+		nameValue = "$(" + towerRunNameEnv + ")"
+	}
 	if len(spec.Nextflow.Command) == 0 {
 		spec.Nextflow.Command = []string{
 			"nextflow",
@@ -250,7 +451,8 @@ func validateLaunch(nfLaunch batchv1alpha1.NextflowLaunch) (batchv1alpha1.Nextfl
 			"-w", escape(spec.K8s["workDir"]),
 			profileArg, profileName,
 			revisionArg, revisionName,
-			escape(spec.Pipeline.Source),
+			nameArg, nameValue,
+			escape(pipelineTarget(spec.Pipeline)),
 		}
 	}
 	if spec.Nextflow.Home == "" {
@@ -262,5 +464,19 @@ func validateLaunch(nfLaunch batchv1alpha1.NextflowLaunch) (batchv1alpha1.Nextfl
 		})
 	}
 	nfLaunch.Spec = spec
+
+	// the driver template can only be checked against the complete pod
+	if spec.Driver.PodTemplate != nil {
+		_, err = makeNextflowPod(nfLaunch, "")
+		if err != nil {
+			return nfLaunch, err
+		}
+	}
+	// settings that cannot be expressed in the config, e.g. param names
+	// that are not identifiers, are only found when rendering it
+	_, err = makeNextflowConfig(nfLaunch)
+	if err != nil {
+		return nfLaunch, err
+	}
 	return nfLaunch, nil
 }
@@ -0,0 +1,214 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func testLaunch() batchv1alpha1.NextflowLaunch {
+	return batchv1alpha1.NextflowLaunch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-launch",
+			Namespace: "default",
+		},
+		Spec: batchv1alpha1.NextflowLaunchSpec{
+			Pipeline: batchv1alpha1.NextflowLaunchPipeline{
+				Source: "hello",
+			},
+			K8s: map[string]string{
+				"storageClaimName": "test-pvc",
+			},
+		},
+	}
+}
+
+// Changes to the test launch definition, by description
+type specChanges map[string]func(spec *batchv1alpha1.NextflowLaunchSpec)
+
+// Validate the test launch definition after a change
+func validTestLaunch(t *testing.T, change func(spec *batchv1alpha1.NextflowLaunchSpec)) batchv1alpha1.NextflowLaunch {
+	t.Helper()
+	nfLaunch := testLaunch()
+	change(&nfLaunch.Spec)
+	nfLaunch, err := validateLaunch(nfLaunch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nfLaunch
+}
+
+// Check that each change makes the test launch definition invalid
+func testRejected(t *testing.T, changes specChanges) {
+	t.Helper()
+	for name, change := range changes {
+		nfLaunch := testLaunch()
+		change(&nfLaunch.Spec)
+		if _, err := validateLaunch(nfLaunch); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+// Render the config of a valid launch
+func testConfig(t *testing.T, nfLaunch batchv1alpha1.NextflowLaunch) string {
+	t.Helper()
+	configMap, err := makeNextflowConfig(nfLaunch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return configMap.Data["nextflow.config"]
+}
+
+// Check that a config contains each of the given parts
+func testConfigContains(t *testing.T, config string, parts ...string) {
+	t.Helper()
+	for _, part := range parts {
+		if !strings.Contains(config, part) {
+			t.Errorf("config does not contain %q:\n%s", part, config)
+		}
+	}
+}
+
+// Environment of a container, by variable name
+func containerEnv(container corev1.Container) map[string]string {
+	env := map[string]string{}
+	for _, variable := range container.Env {
+		env[variable.Name] = variable.Value
+	}
+	return env
+}
+
+func TestMakeNextflowConfig(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Params = map[string]string{
+			"outdir":  `/data/$USER\results`,
+			"note":    "it's\nmultiline",
+			"dry_run": "true",
+		}
+		spec.Pod = []map[string]string{
+			{"toleration": "(map)", "key": "spot", "effect": "NoSchedule"},
+		}
+	})
+	first, err := makeNextflowConfig(nfLaunch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := first.Data["nextflow.config"]
+	testConfigContains(t, config,
+		`outdir = '/data/$USER\\results'`,
+		`note = 'it\'s\nmultiline'`,
+		`dry_run = true`,
+		`pod = [[toleration: [effect: 'NoSchedule', key: 'spot']]]`,
+	)
+
+	// rendering is deterministic
+	for i := 0; i < 10; i++ {
+		again, _ := makeNextflowConfig(nfLaunch)
+		if again.Data["nextflow.config"] != config {
+			t.Fatalf("config differs between renderings")
+		}
+		if again.Name != first.Name {
+			t.Fatalf("config map name differs between renderings: %s, %s", first.Name, again.Name)
+		}
+	}
+
+	// a changed config gets a new name
+	nfLaunch.Spec.Params["outdir"] = "/data/other"
+	changed, _ := makeNextflowConfig(nfLaunch)
+	if changed.Name == first.Name || changed.Labels[configHashLabel] == first.Labels[configHashLabel] {
+		t.Errorf("changed config reuses %s", first.Name)
+	}
+}
+
+func TestMakeNextflowConfigError(t *testing.T) {
+	nfLaunch, _ := validateLaunch(testLaunch())
+	nfLaunch.Spec.Params = map[string]string{"not-an-identifier": "x"}
+	_, err := makeNextflowConfig(nfLaunch)
+	if err == nil {
+		t.Error("invalid param name was accepted")
+	}
+	testRejected(t, specChanges{
+		"invalid param name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Params = map[string]string{"not-an-identifier": "x"}
+		},
+	})
+}
+
+func TestConfigFrom(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Params = map[string]string{"outdir": "/data"}
+		spec.ConfigFrom = []batchv1alpha1.NextflowLaunchConfigSource{
+			{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "site"},
+				Key:                  "site.config",
+			}},
+			{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"},
+				Key:                  "aws.config",
+			}},
+		}
+	})
+
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range pod.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	if volume := volumes["nextflow-config-from-0"]; volume.ConfigMap == nil || volume.ConfigMap.Name != "site" {
+		t.Errorf("config map not attached: %+v", volume)
+	}
+	if volume := volumes["nextflow-config-from-1"]; volume.Secret == nil || volume.Secret.SecretName != "credentials" {
+		t.Errorf("secret not attached: %+v", volume)
+	}
+	mounts := map[string]corev1.VolumeMount{}
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount
+	}
+	if mount := mounts["nextflow-config-from-0"]; mount.MountPath != configFromPath+"/00.config" || mount.SubPath != "site.config" || !mount.ReadOnly {
+		t.Errorf("unexpected mount %+v", mount)
+	}
+	if mount := mounts["nextflow-config-from-1"]; mount.MountPath != configFromPath+"/01.config" || mount.SubPath != "aws.config" {
+		t.Errorf("unexpected mount %+v", mount)
+	}
+
+	// included in order, after everything generated by the operator
+	config := testConfig(t, nfLaunch)
+	first := strings.Index(config, "includeConfig '"+configFromPath+"/00.config'")
+	second := strings.Index(config, "includeConfig '"+configFromPath+"/01.config'")
+	if first < 0 || second < first || first < strings.Index(config, "outdir") {
+		t.Errorf("unexpected includes:\n%s", config)
+	}
+
+	testRejected(t, specChanges{
+		"config source without a reference": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ConfigFrom = []batchv1alpha1.NextflowLaunchConfigSource{{}}
+		},
+		"config source with two references": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ConfigFrom = []batchv1alpha1.NextflowLaunchConfigSource{{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "site.config"},
+				SecretKeyRef:    &corev1.SecretKeySelector{Key: "aws.config"},
+			}}
+		},
+	})
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+const (
+	// home directory of root, the user of the default image
+	rootHome = "/root"
+	// name of the init container installing the ssh key
+	sshContainerName = "nextflow-ssh"
+	// where the init container mounts the ssh secret
+	sshSecretPath = "/tmp/nextflow-ssh-secret"
+)
+
+// Public hosts of the supported providers
+var providerHosts = map[string]string{
+	"github":    "github.com",
+	"gitlab":    "gitlab.com",
+	"bitbucket": "bitbucket.org",
+	"gitea":     "gitea.com",
+	"generic":   "",
+}
+
+// Name of the Kubernetes secret holding the generated SCM file of a launch
+func scmSecretName(nfLaunch batchv1alpha1.NextflowLaunch) string {
+	return nfLaunch.Name + "-nextflow-scm"
+}
+
+// Host of a pipeline source, and whether it is accessed over ssh.
+// Bare project names are resolved by Nextflow on GitHub
+func sourceHost(source string) (string, bool, error) {
+	if strings.HasPrefix(source, "git@") {
+		// scp-like syntax: git@host:owner/repository.git
+		host, _, found := strings.Cut(strings.TrimPrefix(source, "git@"), ":")
+		if !found {
+			return "", false, fmt.Errorf("cannot determine the host of %q", source)
+		}
+		return host, true, nil
+	}
+	if !strings.Contains(source, "://") {
+		return providerHosts["github"], false, nil
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", false, err
+	}
+	return u.Hostname(), u.Scheme == "ssh", nil
+}
+
+// Check the credentials of a launch definition and fill in the provider
+// and the server from the source
+func validateCredentials(spec batchv1alpha1.NextflowLaunchSpec) (*batchv1alpha1.NextflowLaunchCredentials, error) {
+	credentials := spec.Pipeline.Credentials
+	if credentials == nil {
+		return nil, nil
+	}
+	credentials = credentials.DeepCopy()
+	if spec.Pipeline.Source == "" {
+		return nil, errors.New("spec.pipeline.credentials requires a repository source")
+	}
+	if spec.Nextflow.ScmSecretName != "" {
+		return nil, errors.New("spec.pipeline.credentials cannot be combined with spec.nextflow.scmSecretName")
+	}
+	if (credentials.BasicAuthSecretName == "") == (credentials.SSHSecretName == "") {
+		return nil, errors.New("spec.pipeline.credentials requires exactly one of basicAuthSecretName and sshSecretName")
+	}
+
+	host, ssh, err := sourceHost(spec.Pipeline.Source)
+	if err != nil {
+		return nil, fmt.Errorf("spec.pipeline.source: %w", err)
+	}
+	if ssh != (credentials.SSHSecretName != "") {
+		if ssh {
+			return nil, errors.New("spec.pipeline.credentials: an ssh source requires sshSecretName")
+		}
+		return nil, errors.New("spec.pipeline.credentials: sshSecretName requires an ssh source (git@host:owner/repository)")
+	}
+	if credentials.Provider == "" {
+		for provider, providerHost := range providerHosts {
+			if providerHost != "" && providerHost == host {
+				credentials.Provider = provider
+			}
+		}
+		if credentials.Provider == "" {
+			return nil, fmt.Errorf("spec.pipeline.credentials.provider cannot be inferred from host %q", host)
+		}
+	}
+	publicHost, ok := providerHosts[credentials.Provider]
+	if !ok {
+		return nil, fmt.Errorf("spec.pipeline.credentials.provider %q is not one of github, gitlab, bitbucket, gitea and generic", credentials.Provider)
+	}
+	if credentials.Provider == "generic" && !ssh {
+		// Nextflow only knows the API of the other providers
+		return nil, errors.New("spec.pipeline.credentials: the generic provider requires sshSecretName")
+	}
+	if credentials.Server == "" && host != publicHost {
+		credentials.Server = "https://" + host
+	}
+	return credentials, nil
+}
+
+// Render the Nextflow SCM file for a username and a password (or token)
+func makeScmFile(credentials batchv1alpha1.NextflowLaunchCredentials, username string, password string) (string, error) {
+	scm := groovy.NewBlock()
+	provider := scm.Scope("providers").Scope(credentials.Provider)
+	if credentials.Server != "" {
+		provider.Set("server", groovy.String(credentials.Server))
+		provider.Set("platform", groovy.String(credentials.Provider))
+	}
+	provider.Set("user", groovy.String(username))
+	provider.Set("password", groovy.String(password))
+	if credentials.Provider == "gitlab" || credentials.Provider == "gitea" {
+		// API access of these providers is authorized with the token
+		provider.Set("token", groovy.String(password))
+	}
+	return scm.Render()
+}
+
+// Construct the secret holding the SCM file of a launch, from the
+// referenced basic-auth secret
+func makeScmSecret(nfLaunch batchv1alpha1.NextflowLaunch, source corev1.Secret) (corev1.Secret, error) {
+	for _, key := range []string{corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey} {
+		if _, ok := source.Data[key]; !ok {
+			return corev1.Secret{}, fmt.Errorf("key %q not found in secret %q", key, source.Name)
+		}
+	}
+	scm, err := makeScmFile(*nfLaunch.Spec.Pipeline.Credentials,
+		string(source.Data[corev1.BasicAuthUsernameKey]), string(source.Data[corev1.BasicAuthPasswordKey]))
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      scmSecretName(nfLaunch),
+			Namespace: nfLaunch.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"scm": []byte(scm),
+		},
+	}, nil
+}
+
+// Home directory of the driver user, where JGit looks for the ssh key and
+// the known hosts. Kubernetes sets HOME to / for a user without an entry
+// in the image's passwd file
+func sshHome(spec batchv1alpha1.NextflowLaunchSpec) string {
+	security := spec.Security
+	if security == nil || security.RunAsUser == nil || *security.RunAsUser == 0 {
+		return rootHome
+	}
+	for _, env := range spec.Driver.Env {
+		if env.Name == "HOME" && env.Value != "" {
+			return env.Value
+		}
+	}
+	return "/"
+}
+
+// Directory of the ssh key and the known hosts in the driver
+func sshPath(spec batchv1alpha1.NextflowLaunchSpec) string {
+	return path.Join(sshHome(spec), ".ssh")
+}
+
+// Install the ssh key and the known hosts in the driver. Secret files are
+// owned by root, so an init container copies them into a memory-backed
+// volume, as the driver user and with the permissions ssh expects
+func mountSSHKey(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	driver := &pod.Spec.Containers[0]
+	home := sshHome(spec)
+	dir := sshPath(spec)
+	// readable by the fsGroup, for a non-root init container
+	secretMode := int32(0440)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name: "nextflow-ssh-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: spec.Pipeline.Credentials.SSHSecretName,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.SSHAuthPrivateKey, Path: "id_rsa"},
+						{Key: "known_hosts", Path: "known_hosts"},
+					},
+					DefaultMode: &secretMode,
+				},
+			},
+		},
+		corev1.Volume{
+			Name:         "nextflow-ssh",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}},
+		},
+	)
+	sshMount := corev1.VolumeMount{Name: "nextflow-ssh", MountPath: dir}
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:  sshContainerName,
+		Image: driver.Image,
+		Command: []string{"sh", "-c", "cp " + sshSecretPath + "/id_rsa " + sshSecretPath + "/known_hosts " + shellQuote(dir) +
+			" && chmod 600 " + shellQuote(dir+"/id_rsa")},
+		Resources:       driver.Resources,
+		SecurityContext: driver.SecurityContext.DeepCopy(),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "nextflow-ssh-secret", MountPath: sshSecretPath, ReadOnly: true},
+			sshMount,
+		},
+	})
+
+	sshMount.ReadOnly = true
+	driver.VolumeMounts = append(driver.VolumeMounts, sshMount)
+	if home != rootHome {
+		// Java does not read HOME, point it to the same directory
+		option := "-Duser.home=" + home
+		env := []corev1.EnvVar{}
+		for _, variable := range driver.Env {
+			if variable.Name == "NXF_OPTS" && variable.ValueFrom == nil {
+				option = variable.Value + " " + option
+				continue
+			}
+			env = append(env, variable)
+		}
+		driver.Env = append(env, corev1.EnvVar{Name: "NXF_OPTS", Value: option})
+	}
+}
+
+// Returned when the ssh secret lacks one of the keys mounted in the driver
+var errMissingKey = errors.New("lacks the key")
+
+// Whether an error comes from an incomplete ssh secret
+func isMissingKey(err error) bool {
+	return errors.Is(err, errMissingKey)
+}
+
+// Check that the ssh secret of a launch holds the key and the known hosts
+func checkSSHSecret(secret corev1.Secret) error {
+	for _, key := range []string{corev1.SSHAuthPrivateKey, "known_hosts"} {
+		if _, ok := secret.Data[key]; !ok {
+			return fmt.Errorf("secret %q %w %q", secret.Name, errMissingKey, key)
+		}
+	}
+	return nil
+}
+
+// Read the ssh secret of a launch and check its keys
+func (r *NextflowLaunchReconciler) checkSSHKey(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) error {
+	var secret corev1.Secret
+	name := types.NamespacedName{
+		Namespace: nfLaunch.Namespace,
+		Name:      nfLaunch.Spec.Pipeline.Credentials.SSHSecretName,
+	}
+	err := r.Get(ctx, name, &secret)
+	if err != nil {
+		return err
+	}
+	return checkSSHSecret(secret)
+}
+
+// Create (or update) the SCM file secret of a launch
+func (r *NextflowLaunchReconciler) createScmSecret(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) error {
+	var source corev1.Secret
+	name := types.NamespacedName{
+		Namespace: nfLaunch.Namespace,
+		Name:      nfLaunch.Spec.Pipeline.Credentials.BasicAuthSecretName,
+	}
+	err := r.Get(ctx, name, &source)
+	if err != nil {
+		return err
+	}
+	scm, err := makeScmSecret(nfLaunch, source)
+	if err != nil {
+		return err
+	}
+	secret := corev1.Secret{ObjectMeta: scm.ObjectMeta}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &secret, func() error {
+		secret.Type = scm.Type
+		secret.Data = scm.Data
+		return ctrl.SetControllerReference(&nfLaunch, &secret, r.Scheme)
+	})
+	return err
+}
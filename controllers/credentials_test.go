@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestCredentials(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline.Source = "https://git.example.com/group/pipeline"
+		spec.Pipeline.Credentials = &batchv1alpha1.NextflowLaunchCredentials{
+			Provider:            "gitlab",
+			BasicAuthSecretName: "gitlab-token",
+		}
+	})
+	if nfLaunch.Spec.Nextflow.ScmSecretName != "test-launch-nextflow-scm" {
+		t.Errorf("SCM secret not mounted: %q", nfLaunch.Spec.Nextflow.ScmSecretName)
+	}
+	source := corev1.Secret{Data: map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte("ci"),
+		corev1.BasicAuthPasswordKey: []byte("glpat-123"),
+	}}
+	secret, err := makeScmSecret(nfLaunch, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `providers {
+    gitlab {
+        server = 'https://git.example.com'
+        platform = 'gitlab'
+        user = 'ci'
+        password = 'glpat-123'
+        token = 'glpat-123'
+    }
+}
+`
+	if got := string(secret.Data["scm"]); got != want {
+		t.Errorf("unexpected SCM file:\n%s", got)
+	}
+
+	sshLaunch := func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline.Source = "git@bitbucket.org:team/pipeline.git"
+		spec.Pipeline.Credentials = &batchv1alpha1.NextflowLaunchCredentials{SSHSecretName: "deploy-key"}
+	}
+	nfLaunch = validTestLaunch(t, sshLaunch)
+	if nfLaunch.Spec.Pipeline.Credentials.Provider != "bitbucket" {
+		t.Errorf("provider not inferred: %+v", nfLaunch.Spec.Pipeline.Credentials)
+	}
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	mounted := false
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		mounted = mounted || mount.MountPath == "/root/.ssh"
+	}
+	if !mounted {
+		t.Errorf("ssh key not mounted: %+v", pod.Spec.Containers[0].VolumeMounts)
+	}
+
+	// a non-root driver finds the key in its own home, copied by the init container
+	nfLaunch = validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		sshLaunch(spec)
+		spec.Security = &batchv1alpha1.NextflowLaunchSecurity{Restricted: true}
+	})
+	pod, _ = makeNextflowPod(nfLaunch, "config")
+	init := pod.Spec.InitContainers[len(pod.Spec.InitContainers)-1]
+	if init.Name != sshContainerName || init.SecurityContext == nil || init.VolumeMounts[1].MountPath != "/.ssh" ||
+		!strings.Contains(init.Command[2], "chmod 600 '/.ssh/id_rsa'") {
+		t.Errorf("unexpected init container %+v", init)
+	}
+	if options := containerEnv(pod.Spec.Containers[0])["NXF_OPTS"]; options != "-Duser.home=/" {
+		t.Errorf("unexpected NXF_OPTS %q", options)
+	}
+
+	secret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy-key"},
+		Data:       map[string][]byte{corev1.SSHAuthPrivateKey: []byte("key")},
+	}
+	if err := checkSSHSecret(secret); !isMissingKey(err) {
+		t.Errorf("secret without known_hosts accepted: %v", err)
+	}
+	secret.Data["known_hosts"] = []byte("bitbucket.org ssh-ed25519 AAAA")
+	if err := checkSSHSecret(secret); err != nil {
+		t.Error(err)
+	}
+
+	credentials := func(source string, credentials batchv1alpha1.NextflowLaunchCredentials) func(*batchv1alpha1.NextflowLaunchSpec) {
+		return func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Pipeline.Source = source
+			spec.Pipeline.Credentials = &credentials
+		}
+	}
+	testRejected(t, specChanges{
+		"basic auth without a provider": credentials("https://git.example.com/group/pipeline",
+			batchv1alpha1.NextflowLaunchCredentials{BasicAuthSecretName: "token"}),
+		"ssh key for a project name": credentials("nf-core/rnaseq",
+			batchv1alpha1.NextflowLaunchCredentials{SSHSecretName: "deploy-key"}),
+		"basic auth for an ssh URL": credentials("git@github.com:nf-core/rnaseq.git",
+			batchv1alpha1.NextflowLaunchCredentials{BasicAuthSecretName: "token"}),
+		"unknown provider": credentials("https://github.com/nf-core/rnaseq",
+			batchv1alpha1.NextflowLaunchCredentials{Provider: "generic", BasicAuthSecretName: "token"}),
+	})
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+// Name of the driver container in spec.driver.podTemplate
+const driverContainerName = "nextflow"
+
+// Names of the init containers the operator may add to the driver pod,
+// which a pod template cannot use
+var operatorInitContainers = []string{
+	prefetchContainerName, sshContainerName, homeContainerName,
+	offlineContainerName, pluginsContainerName, secretsContainerName,
+}
+
+// Node labels of spot/preemptible capacity, with the values marking it,
+// as set by the common cloud providers and autoscalers
+var spotNodeLabels = []struct{ key, value string }{
+	{"cloud.google.com/gke-spot", "true"},
+	{"cloud.google.com/gke-preemptible", "true"},
+	{"eks.amazonaws.com/capacityType", "SPOT"},
+	{"karpenter.sh/capacity-type", "spot"},
+	{"kubernetes.azure.com/scalesetpriority", "spot"},
+}
+
+// Node affinity keeping a pod off spot nodes, combined with the given one.
+// The expressions are added to every required term, as terms are ORed
+func onDemandAffinity(affinity *corev1.Affinity) *corev1.Affinity {
+	var expressions []corev1.NodeSelectorRequirement
+	for _, label := range spotNodeLabels {
+		expressions = append(expressions, corev1.NodeSelectorRequirement{
+			Key:      label.key,
+			Operator: corev1.NodeSelectorOpNotIn,
+			Values:   []string{label.value},
+		})
+	}
+
+	affinity = affinity.DeepCopy()
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		required = &corev1.NodeSelector{}
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	}
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		term.MatchExpressions = append(term.MatchExpressions, expressions...)
+	}
+	return affinity
+}
+
+// Set the node placement settings of the driver pod
+func placeDriver(pod *corev1.Pod, driver batchv1alpha1.NextflowLaunchDriver) {
+	pod.Spec.NodeSelector = driver.NodeSelector
+	pod.Spec.Affinity = driver.Affinity
+	if driver.OnDemand {
+		pod.Spec.Affinity = onDemandAffinity(driver.Affinity)
+	}
+	pod.Spec.TopologySpreadConstraints = driver.TopologySpreadConstraints
+	pod.Spec.PriorityClassName = driver.PriorityClassName
+}
+
+// Merge a partial pod template onto the driver pod (strategic merge patch,
+// as in kubectl patch), then check that the operator-managed parts survived
+func applyPodTemplate(pod *corev1.Pod, template *corev1.PodTemplateSpec) error {
+	if template == nil {
+		return nil
+	}
+
+	// the actual container name is generated, refer to it by a fixed one
+	driver := pod.Spec.Containers[0].Name
+	template = template.DeepCopy()
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == driverContainerName {
+			template.Spec.Containers[i].Name = driver
+		}
+	}
+	// containers is always serialized, and null would remove them all
+	if len(template.Spec.Containers) == 0 {
+		template.Spec.Containers = []corev1.Container{{Name: driver}}
+	}
+
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, corev1.Pod{})
+	if err != nil {
+		return fmt.Errorf("spec.driver.podTemplate cannot be applied: %w", err)
+	}
+	var result corev1.Pod
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return fmt.Errorf("spec.driver.podTemplate cannot be applied: %w", err)
+	}
+
+	// the merge puts the init containers of the template first; those of
+	// the operator have to run before them
+	var initContainers, added []corev1.Container
+	for _, init := range pod.Spec.InitContainers {
+		for _, merged := range result.Spec.InitContainers {
+			if merged.Name == init.Name {
+				initContainers = append(initContainers, merged)
+			}
+		}
+	}
+	for _, merged := range result.Spec.InitContainers {
+		if !hasContainer(pod.Spec.InitContainers, merged.Name) {
+			added = append(added, merged)
+		}
+	}
+	result.Spec.InitContainers = append(initContainers, added...)
+
+	if err := checkProtectedFields(*pod, result); err != nil {
+		return err
+	}
+	// keep the driver container first, extra containers follow it
+	for i, container := range result.Spec.Containers {
+		if container.Name == driver {
+			containers := append([]corev1.Container{container}, result.Spec.Containers[:i]...)
+			result.Spec.Containers = append(containers, result.Spec.Containers[i+1:]...)
+			break
+		}
+	}
+	*pod = result
+	return nil
+}
+
+// Whether a list of containers has one with the given name
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that a pod template did not change the name, the restart policy,
+// the init containers, the volumes or the volume mounts of the driver pod
+// set by the operator
+func checkProtectedFields(original corev1.Pod, merged corev1.Pod) error {
+	if merged.Name != original.Name || merged.Namespace != original.Namespace {
+		return fmt.Errorf("spec.driver.podTemplate cannot change the name or namespace of the driver pod")
+	}
+	if merged.Spec.RestartPolicy != original.Spec.RestartPolicy {
+		return fmt.Errorf("spec.driver.podTemplate cannot change the restart policy of the driver pod")
+	}
+
+	// the init containers of the operator are unchanged and run first. The
+	// merge round-trips through JSON, hence the semantic comparison
+	// (quantities, empty lists)
+	initContainers := merged.Spec.InitContainers
+	for i, init := range original.Spec.InitContainers {
+		if i >= len(initContainers) || !equality.Semantic.DeepEqual(initContainers[i], init) {
+			return fmt.Errorf("spec.driver.podTemplate cannot change the %s init container", init.Name)
+		}
+	}
+	for _, name := range operatorInitContainers {
+		if hasContainer(initContainers[len(original.Spec.InitContainers):], name) {
+			return fmt.Errorf("spec.driver.podTemplate cannot add the %s init container, which is reserved", name)
+		}
+	}
+
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range merged.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	for _, volume := range original.Spec.Volumes {
+		if !reflect.DeepEqual(volumes[volume.Name], volume) {
+			return fmt.Errorf("spec.driver.podTemplate cannot change the volume %s", volume.Name)
+		}
+	}
+
+	var container *corev1.Container
+	for i := range merged.Spec.Containers {
+		if merged.Spec.Containers[i].Name == original.Spec.Containers[0].Name {
+			container = &merged.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return fmt.Errorf("spec.driver.podTemplate cannot remove the %s container", driverContainerName)
+	}
+	mounts := map[string]corev1.VolumeMount{}
+	for _, mount := range container.VolumeMounts {
+		mounts[mount.MountPath] = mount
+	}
+	for _, mount := range original.Spec.Containers[0].VolumeMounts {
+		if !reflect.DeepEqual(mounts[mount.MountPath], mount) {
+			return fmt.Errorf("spec.driver.podTemplate cannot change the mount at %s", mount.MountPath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestDriverPodTemplate(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline.Prefetch = true
+		spec.Driver.PodTemplate = &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"team": "genomics"}},
+			Spec: corev1.PodSpec{
+				NodeSelector:     map[string]string{"node-pool": "drivers"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				InitContainers:   []corev1.Container{{Name: "setup", Image: "busybox"}},
+				Containers: []corev1.Container{
+					{Name: "sidecar", Image: "busybox"},
+					{Name: "nextflow", Env: []corev1.EnvVar{{Name: "NXF_ANSI_LOG", Value: "false"}}},
+				},
+			},
+		}
+	})
+	pod, err := makeNextflowPod(nfLaunch, "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Annotations["team"] != "genomics" || pod.Spec.NodeSelector["node-pool"] != "drivers" ||
+		len(pod.Spec.ImagePullSecrets) != 1 {
+		t.Errorf("template was not merged: %+v", pod)
+	}
+	if len(pod.Spec.Containers) != 2 || pod.Spec.Containers[1].Name != "sidecar" {
+		t.Fatalf("unexpected containers %+v", pod.Spec.Containers)
+	}
+	driver := pod.Spec.Containers[0]
+	if driver.Image == "" || len(driver.VolumeMounts) < 2 {
+		t.Errorf("driver container was replaced: %+v", driver)
+	}
+	if _, ok := containerEnv(driver)["NXF_ANSI_LOG"]; !ok {
+		t.Errorf("driver env was not merged: %+v", driver.Env)
+	}
+	// the init containers of the operator run first
+	if len(pod.Spec.InitContainers) != 2 || pod.Spec.InitContainers[0].Name != prefetchContainerName {
+		t.Errorf("unexpected init containers %+v", pod.Spec.InitContainers)
+	}
+
+	// a template changing only the metadata keeps the driver
+	nfLaunch = validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Driver.PodTemplate = &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "genomics"}},
+		}
+	})
+	if pod, _ := makeNextflowPod(nfLaunch, "config"); len(pod.Spec.Containers) != 1 {
+		t.Errorf("unexpected containers %+v", pod.Spec.Containers)
+	}
+
+	// the parts of the pod set by the operator cannot be changed
+	withTemplate := func(spec corev1.PodSpec) func(*batchv1alpha1.NextflowLaunchSpec) {
+		return func(launch *batchv1alpha1.NextflowLaunchSpec) {
+			launch.Pipeline.Prefetch = true
+			launch.Driver.PodTemplate = &corev1.PodTemplateSpec{Spec: spec}
+		}
+	}
+	testRejected(t, specChanges{
+		"template changing the storage mount": withTemplate(corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:         "nextflow",
+				VolumeMounts: []corev1.VolumeMount{{Name: "other", MountPath: defaultMountPath}},
+			}},
+		}),
+		"template changing the restart policy": withTemplate(corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+		}),
+		"template changing the prefetch command": withTemplate(corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: prefetchContainerName, Command: []string{"true"}}},
+		}),
+		"template changing the prefetch image": withTemplate(corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: prefetchContainerName, Image: "busybox"}},
+		}),
+		"template adding a reserved init container": withTemplate(corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: pluginsContainerName, Image: "busybox"}},
+		}),
+	})
+}
+
+func TestDriverPlacement(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Driver.NodeSelector = map[string]string{"node-pool": "system"}
+		spec.Driver.PriorityClassName = "high"
+		spec.Driver.OnDemand = true
+		spec.Driver.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+						}},
+						{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}},
+						}},
+					},
+				},
+			},
+		}
+	})
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	if pod.Spec.NodeSelector["node-pool"] != "system" || pod.Spec.PriorityClassName != "high" {
+		t.Errorf("placement settings not applied: %+v", pod.Spec)
+	}
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for i, term := range terms {
+		if len(term.MatchExpressions) != 1+len(spotNodeLabels) {
+			t.Errorf("term %d does not exclude spot nodes: %+v", i, term)
+		}
+	}
+	// the launch definition itself is not modified
+	original := nfLaunch.Spec.Driver.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(original.NodeSelectorTerms[0].MatchExpressions) != 1 {
+		t.Error("affinity of the launch definition was modified")
+	}
+}
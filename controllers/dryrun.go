@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+// Annotation requesting a dry run, as an alternative to spec.dryRun
+const dryRunAnnotation = "batch.mnm.bio/dry-run"
+
+// Check if the launch should only be rendered, not run
+func isDryRun(nfLaunch batchv1alpha1.NextflowLaunch) bool {
+	if nfLaunch.Spec.DryRun {
+		return true
+	}
+	value, err := strconv.ParseBool(nfLaunch.Annotations[dryRunAnnotation])
+	return err == nil && value
+}
+
+// Render the Nextflow config and the driver pod of a launch,
+// without creating any objects
+func makeDryRun(nfLaunch batchv1alpha1.NextflowLaunch) (*batchv1alpha1.NextflowLaunchDryRun, error) {
+
+	if nfLaunch.Spec.Tower != nil {
+		nfLaunch.Status.Tower = &batchv1alpha1.NextflowLaunchTowerStatus{
+			RunName: towerRunName(nfLaunch),
+		}
+	}
+
+	configMap, err := makeNextflowConfig(nfLaunch)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := makeNextflowPod(nfLaunch, configMap.Name)
+	if err != nil {
+		return nil, err
+	}
+	container := pod.Spec.Containers[0]
+
+	dryRun := batchv1alpha1.NextflowLaunchDryRun{
+		ObservedGeneration: nfLaunch.Generation,
+		Config:             configMap.Data["nextflow.config"],
+		Command:            append(append([]string{}, container.Command...), container.Args...),
+		Pod: batchv1alpha1.NextflowLaunchPodSummary{
+			Image:     container.Image,
+			Resources: container.Resources,
+		},
+	}
+	for _, env := range container.Env {
+		dryRun.Pod.Env = append(dryRun.Pod.Env, envSummary(env))
+	}
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range pod.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	for _, mount := range container.VolumeMounts {
+		dryRun.Pod.Mounts = append(dryRun.Pod.Mounts, mountSummary(mount, volumes[mount.Name]))
+	}
+	return &dryRun, nil
+}
+
+// Describe an environment variable without revealing referenced values
+func envSummary(env corev1.EnvVar) string {
+	source := env.ValueFrom
+	switch {
+	case source == nil:
+		return env.Name + "=" + env.Value
+	case source.SecretKeyRef != nil:
+		return env.Name + " from secret " + source.SecretKeyRef.Name + "/" + source.SecretKeyRef.Key
+	case source.ConfigMapKeyRef != nil:
+		return env.Name + " from configMap " + source.ConfigMapKeyRef.Name + "/" + source.ConfigMapKeyRef.Key
+	case source.FieldRef != nil:
+		return env.Name + " from field " + source.FieldRef.FieldPath
+	default:
+		return env.Name + " from resource"
+	}
+}
+
+// Describe a volume mount as "<mount path> <- <kind> <name>[/<sub path>]"
+func mountSummary(mount corev1.VolumeMount, volume corev1.Volume) string {
+	source := "volume " + volume.Name
+	switch {
+	case volume.ConfigMap != nil:
+		source = "configMap " + volume.ConfigMap.Name
+	case volume.Secret != nil:
+		source = "secret " + volume.Secret.SecretName
+	case volume.PersistentVolumeClaim != nil:
+		source = "persistentVolumeClaim " + volume.PersistentVolumeClaim.ClaimName
+	case volume.EmptyDir != nil:
+		source = "emptyDir"
+	}
+	if mount.SubPath != "" {
+		source += "/" + mount.SubPath
+	}
+	summary := mount.MountPath + " <- " + source
+	if mount.ReadOnly {
+		summary += " (read-only)"
+	}
+	return summary
+}
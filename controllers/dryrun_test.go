@@ -0,0 +1,65 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMakeDryRun(t *testing.T) {
+	nfLaunch := testLaunch()
+	nfLaunch.Generation = 3
+	nfLaunch.Spec.Params = map[string]string{"input": "samples.csv"}
+	if isDryRun(nfLaunch) {
+		t.Fatal("launch without dry run settings is a dry run")
+	}
+	nfLaunch.Annotations = map[string]string{dryRunAnnotation: "true"}
+	if !isDryRun(nfLaunch) {
+		t.Fatal("dry run annotation is ignored")
+	}
+
+	nfLaunch, err := validateLaunch(nfLaunch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dryRun, err := makeDryRun(nfLaunch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dryRun.ObservedGeneration != 3 {
+		t.Errorf("observed generation is %d", dryRun.ObservedGeneration)
+	}
+	if !strings.Contains(dryRun.Config, "input = 'samples.csv'") {
+		t.Errorf("config is not rendered:\n%s", dryRun.Config)
+	}
+	command := strings.Join(dryRun.Command, " ")
+	if !strings.Contains(command, "run -process.executor k8s") || !strings.HasSuffix(command, "hello") {
+		t.Errorf("unexpected command %q", command)
+	}
+	if dryRun.Pod.Image != nfLaunch.Spec.Nextflow.Image+":"+nfLaunch.Spec.Nextflow.Version {
+		t.Errorf("unexpected image %q", dryRun.Pod.Image)
+	}
+	want := defaultMountPath + " <- persistentVolumeClaim test-pvc"
+	found := false
+	for _, mount := range dryRun.Pod.Mounts {
+		found = found || mount == want
+	}
+	if !found {
+		t.Errorf("mounts %q do not contain %q", dryRun.Pod.Mounts, want)
+	}
+}
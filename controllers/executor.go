@@ -0,0 +1,223 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+var (
+	// Nextflow durations, e.g. "500ms", "5 sec", "1h 30min"
+	durationPattern = regexp.MustCompile(`^\s*(\d+(\.\d+)?\s*[a-z]+\s*)+$`)
+	// Nextflow rate limits, e.g. "10/s", "50 / 2 min"
+	ratePattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*/\s*(\d*(?:\.\d+)?)\s*([a-z]+)\s*$`)
+
+	rateUnits = map[string]float64{
+		"s": 1, "sec": 1, "second": 1, "seconds": 1,
+		"m": 60, "min": 60, "minute": 60, "minutes": 60,
+		"h": 3600, "hour": 3600, "hours": 3600,
+		"d": 86400, "day": 86400, "days": 86400,
+	}
+
+	// options of nextflow run that would override the limited settings:
+	// the queue size, and config files read after the generated one
+	limitedOptions = map[string]bool{
+		"-qs": true, "-queue-size": true,
+		"-c": true, "-config": true, "-C": true,
+	}
+)
+
+// ExecutorLimits are operator-wide maximums of the executor settings,
+// protecting the API server from launches submitting too many workers
+type ExecutorLimits struct {
+	// maximum executor.queueSize (0 means no limit)
+	MaxQueueSize int32
+	// maximum executor.submitRateLimit, in tasks per second (0 means no limit)
+	MaxSubmitRate float64
+}
+
+// Parse a Nextflow rate limit and return it in tasks per second
+func parseSubmitRate(s string) (float64, error) {
+	m := ratePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a valid rate limit", s)
+	}
+	unit, ok := rateUnits[m[3]]
+	if !ok {
+		return 0, fmt.Errorf("%q is not a valid rate limit unit", m[3])
+	}
+	tasks, _ := strconv.ParseFloat(m[1], 64)
+	interval := 1.0
+	if m[2] != "" {
+		interval, _ = strconv.ParseFloat(m[2], 64)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("%q has an empty interval", s)
+	}
+	return tasks / (interval * unit), nil
+}
+
+// Check the executor section of a launch definition
+func validateExecutor(executor *batchv1alpha1.NextflowLaunchExecutor) error {
+	if executor == nil {
+		return nil
+	}
+	if executor.QueueSize != nil && *executor.QueueSize < 1 {
+		return fmt.Errorf("spec.executor.queueSize must be positive")
+	}
+	if executor.SubmitRateLimit != "" {
+		if _, err := parseSubmitRate(executor.SubmitRateLimit); err != nil {
+			return fmt.Errorf("spec.executor.submitRateLimit: %w", err)
+		}
+	}
+	durations := map[string]string{
+		"pollInterval":    executor.PollInterval,
+		"exitReadTimeout": executor.ExitReadTimeout,
+	}
+	if retry := executor.Retry; retry != nil {
+		durations["retry.delay"] = retry.Delay
+		durations["retry.maxDelay"] = retry.MaxDelay
+		if retry.MaxAttempt != nil && *retry.MaxAttempt < 0 {
+			return fmt.Errorf("spec.executor.retry.maxAttempt cannot be negative")
+		}
+		if retry.Jitter != "" {
+			jitter, err := strconv.ParseFloat(retry.Jitter, 64)
+			if err != nil || jitter < 0 || jitter > 1 {
+				return fmt.Errorf("spec.executor.retry.jitter must be a number between 0 and 1")
+			}
+		}
+	}
+	for _, key := range sortedKeys(durations) {
+		if durations[key] != "" && !durationPattern.MatchString(durations[key]) {
+			return fmt.Errorf("spec.executor.%s %q is not a valid duration", key, durations[key])
+		}
+	}
+	return nil
+}
+
+// Enforce the operator-wide limits on a launch definition. Settings above
+// the limits are rejected, missing ones are capped at the limits (the queue
+// size at Nextflow's default, if lower), so that they are always written
+// to the config. Anything that could override the config is rejected
+func (l ExecutorLimits) enforce(spec *batchv1alpha1.NextflowLaunchSpec) error {
+
+	if l.MaxQueueSize <= 0 && l.MaxSubmitRate <= 0 {
+		return nil
+	}
+	if len(spec.Nextflow.Command) > 0 {
+		return errors.New("spec.nextflow.command cannot be customized while the operator limits the executor settings")
+	}
+	for i, arg := range spec.Nextflow.Args {
+		option, _, _ := strings.Cut(arg, "=")
+		if limitedOptions[option] {
+			return fmt.Errorf("spec.nextflow.args[%d] %q is not allowed while the operator limits the executor settings", i, option)
+		}
+	}
+	executor := spec.Executor.DeepCopy()
+	if executor == nil {
+		executor = &batchv1alpha1.NextflowLaunchExecutor{}
+	}
+
+	if l.MaxQueueSize > 0 {
+		if executor.QueueSize == nil {
+			// Nextflow's default queue size for the k8s executor
+			queueSize := int32(100)
+			if l.MaxQueueSize < queueSize {
+				queueSize = l.MaxQueueSize
+			}
+			executor.QueueSize = &queueSize
+		} else if *executor.QueueSize > l.MaxQueueSize {
+			return fmt.Errorf("spec.executor.queueSize exceeds the maximum of %d", l.MaxQueueSize)
+		}
+	}
+
+	if l.MaxSubmitRate > 0 {
+		if executor.SubmitRateLimit == "" {
+			executor.SubmitRateLimit = strconv.FormatFloat(l.MaxSubmitRate, 'f', -1, 64) + "/1s"
+		} else {
+			rate, err := parseSubmitRate(executor.SubmitRateLimit)
+			if err != nil {
+				return fmt.Errorf("spec.executor.submitRateLimit: %w", err)
+			}
+			if rate > l.MaxSubmitRate {
+				return fmt.Errorf("spec.executor.submitRateLimit exceeds the maximum of %g tasks per second", l.MaxSubmitRate)
+			}
+		}
+	}
+
+	spec.Executor = executor
+	return nil
+}
+
+// Repeat the limited executor settings at the end of the config, under
+// both the generic and the k8s-specific names (which Nextflow reads first),
+// so that neither the included files nor the pipeline can override them
+func enforceExecutor(config *groovy.Block, executor *batchv1alpha1.NextflowLaunchExecutor) {
+	if executor == nil {
+		return
+	}
+	for _, prefix := range []string{"executor.", "executor.$k8s."} {
+		if executor.QueueSize != nil {
+			config.Set(prefix+"queueSize", groovy.Int(*executor.QueueSize))
+		}
+		if executor.SubmitRateLimit != "" {
+			config.Set(prefix+"submitRateLimit", groovy.String(executor.SubmitRateLimit))
+		}
+	}
+}
+
+// Add the executor scope to the config
+func configureExecutor(config *groovy.Block, executor *batchv1alpha1.NextflowLaunchExecutor) {
+	if executor == nil {
+		return
+	}
+	scope := config.Scope("executor")
+	if executor.QueueSize != nil {
+		scope.Set("queueSize", groovy.Int(*executor.QueueSize))
+	}
+	if executor.PollInterval != "" {
+		scope.Set("pollInterval", groovy.String(executor.PollInterval))
+	}
+	if executor.SubmitRateLimit != "" {
+		scope.Set("submitRateLimit", groovy.String(executor.SubmitRateLimit))
+	}
+	if executor.ExitReadTimeout != "" {
+		scope.Set("exitReadTimeout", groovy.String(executor.ExitReadTimeout))
+	}
+	if retry := executor.Retry; retry != nil {
+		if retry.Delay != "" {
+			scope.Set("retry.delay", groovy.String(retry.Delay))
+		}
+		if retry.MaxDelay != "" {
+			scope.Set("retry.maxDelay", groovy.String(retry.MaxDelay))
+		}
+		if retry.MaxAttempt != nil {
+			scope.Set("retry.maxAttempt", groovy.Int(*retry.MaxAttempt))
+		}
+		if retry.Jitter != "" {
+			jitter, _ := strconv.ParseFloat(retry.Jitter, 64)
+			scope.Set("retry.jitter", groovy.Float(jitter))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestParseSubmitRate(t *testing.T) {
+	cases := map[string]float64{
+		"10/s":       10,
+		"50/2min":    50.0 / 120,
+		"5 / 1 sec":  5,
+		"3600/1hour": 1,
+	}
+	for in, want := range cases {
+		got, err := parseSubmitRate(in)
+		if err != nil {
+			t.Errorf("parseSubmitRate(%q): %v", in, err)
+		} else if got != want {
+			t.Errorf("parseSubmitRate(%q) = %g, want %g", in, got, want)
+		}
+	}
+	for _, in := range []string{"10", "10/0s", "fast", "10/1 fortnight"} {
+		if _, err := parseSubmitRate(in); err == nil {
+			t.Errorf("parseSubmitRate(%q) succeeded", in)
+		}
+	}
+}
+
+func TestExecutorLimits(t *testing.T) {
+	limits := ExecutorLimits{MaxQueueSize: 50, MaxSubmitRate: 10}
+
+	spec := testLaunch().Spec
+	if err := limits.enforce(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if *spec.Executor.QueueSize != 50 || spec.Executor.SubmitRateLimit != "10/1s" {
+		t.Errorf("limits not applied: %+v", spec.Executor)
+	}
+
+	spec = testLaunch().Spec
+	if err := (ExecutorLimits{MaxQueueSize: 1000}).enforce(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if *spec.Executor.QueueSize != 100 {
+		t.Errorf("default queue size not set: %+v", spec.Executor)
+	}
+
+	queueSize := int32(5000)
+	rejected := specChanges{
+		"queue size above the limit": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Executor = &batchv1alpha1.NextflowLaunchExecutor{QueueSize: &queueSize}
+		},
+		"submit rate above the limit": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Executor = &batchv1alpha1.NextflowLaunchExecutor{SubmitRateLimit: "1000/1min"}
+		},
+		"custom command": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Command = []string{"nextflow", "run", "-qs", "5000", "hello"}
+		},
+		"-qs argument": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Args = []string{"-resume", "-qs", "5000"}
+		},
+		"-queue-size argument": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Args = []string{"-queue-size=5000"}
+		},
+		"extra config file": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Args = []string{"-c", "/data/fast.config"}
+		},
+	}
+	for name, modify := range rejected {
+		spec := testLaunch().Spec
+		modify(&spec)
+		if err := limits.enforce(&spec); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+
+	spec = testLaunch().Spec
+	spec.Nextflow.Args = []string{"-resume", "--input", "samples.csv"}
+	if err := limits.enforce(&spec); err != nil {
+		t.Errorf("plain arguments rejected: %v", err)
+	}
+}
+
+func TestEnforceExecutor(t *testing.T) {
+	configFrom := []batchv1alpha1.NextflowLaunchConfigSource{{
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "site"},
+			Key:                  "site.config",
+		},
+	}}
+	limits := ExecutorLimits{MaxQueueSize: 1000, MaxSubmitRate: 10}
+	want := `executor.queueSize = 100
+executor.submitRateLimit = '10/1s'
+executor.$k8s.queueSize = 100
+executor.$k8s.submitRateLimit = '10/1s'
+`
+	// the limits end the config, with or without included files
+	for _, sources := range [][]batchv1alpha1.NextflowLaunchConfigSource{nil, configFrom} {
+		nfLaunch := testLaunch()
+		nfLaunch.Spec.ConfigFrom = sources
+		if err := limits.enforce(&nfLaunch.Spec); err != nil {
+			t.Fatal(err)
+		}
+		nfLaunch, err := validateLaunch(nfLaunch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configMap, err := makeNextflowConfig(nfLaunch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config := configMap.Data["nextflow.config"]; !strings.HasSuffix(config, want) {
+			t.Errorf("limits not at the end of the config (%d includes):\n%s", len(sources), config)
+		}
+	}
+}
+
+func TestConfigureExecutor(t *testing.T) {
+	queueSize := int32(200)
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Executor = &batchv1alpha1.NextflowLaunchExecutor{
+			QueueSize:    &queueSize,
+			PollInterval: "10 sec",
+			Retry:        &batchv1alpha1.NextflowLaunchExecutorRetry{Jitter: "0.25"},
+		}
+	})
+	testConfigContains(t, testConfig(t, nfLaunch),
+		"executor {\n    queueSize = 200\n    pollInterval = '10 sec'\n    retry.jitter = 0.25\n}\n")
+}
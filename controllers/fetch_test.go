@@ -0,0 +1,74 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestIsInternalHost(t *testing.T) {
+	cases := map[string]bool{
+		"raw.githubusercontent.com":          false,
+		"93.184.216.34":                      false,
+		"localhost":                          true,
+		"schemas":                            true,
+		"schemas.default.svc":                true,
+		"schemas.default.svc.cluster.local.": true,
+		"metadata.google.internal":           true,
+		"127.0.0.1":                          true,
+		"169.254.169.254":                    true,
+		"10.96.0.1":                          true,
+		"::1":                                true,
+		"fe80::1":                            true,
+	}
+	for host, want := range cases {
+		if got := isInternalHost(host); got != want {
+			t.Errorf("isInternalHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestFetchPublic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	if _, err := fetchPublic(context.Background(), server.URL, 1024); err == nil {
+		t.Error("fetching a loopback URL succeeded")
+	}
+	// the address is checked again when connecting, e.g. after a DNS lookup
+	if _, err := publicClient.Get(server.URL); err == nil {
+		t.Error("connecting to a loopback address succeeded")
+	}
+
+	for _, url := range []string{
+		"http://169.254.169.254/latest/meta-data",
+		"http://schemas.default.svc.cluster.local/nextflow_schema.json",
+		"https://10.0.0.5/schema.json",
+	} {
+		schema := &batchv1alpha1.NextflowLaunchParamsSchema{URL: url}
+		if err := validateParamsSchema(schema); err == nil {
+			t.Errorf("internal schema URL %s was accepted", url)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	hookPreRun    = "preRun"
+	hookOnSuccess = "onSuccess"
+	hookOnFailure = "onFailure"
+	hookAlways    = "always"
+
+	hookSucceeded = "Succeeded"
+	hookFailed    = "Failed"
+	hookRunning   = "Running"
+	hookPending   = "Pending"
+	hookSkipped   = "Skipped"
+)
+
+// A list of hooks run together, in order
+type hookPhase struct {
+	name   string
+	suffix string
+	hooks  []batchv1alpha1.NextflowLaunchHook
+}
+
+// Phases of hooks run after the driver has finished with the given outcome
+func postRunPhases(hooks *batchv1alpha1.NextflowLaunchHooks, outcome string) []hookPhase {
+	if hooks == nil {
+		return nil
+	}
+	phases := []hookPhase{{hookOnSuccess, "on-success", hooks.OnSuccess}}
+	if outcome == statusFailed {
+		phases[0] = hookPhase{hookOnFailure, "on-failure", hooks.OnFailure}
+	}
+	phases = append(phases, hookPhase{hookAlways, "always", hooks.Always})
+	return phases
+}
+
+// Container name of a hook
+func hookContainerName(hook batchv1alpha1.NextflowLaunchHook) string {
+	return "hook-" + hook.Name
+}
+
+// Check the hooks section of a launch definition
+func validateHooks(hooks *batchv1alpha1.NextflowLaunchHooks) error {
+	if hooks == nil {
+		return nil
+	}
+	for _, phase := range []hookPhase{
+		{hookPreRun, "", hooks.PreRun},
+		{hookOnSuccess, "", hooks.OnSuccess},
+		{hookOnFailure, "", hooks.OnFailure},
+		{hookAlways, "", hooks.Always},
+	} {
+		seen := map[string]bool{}
+		for i, hook := range phase.hooks {
+			if errs := validation.IsDNS1123Label(hookContainerName(hook)); len(errs) > 0 {
+				return fmt.Errorf("spec.hooks.%s[%d].name %q is invalid: %s", phase.name, i, hook.Name, errs[0])
+			}
+			if seen[hook.Name] {
+				return fmt.Errorf("spec.hooks.%s[%d].name %q is duplicated", phase.name, i, hook.Name)
+			}
+			seen[hook.Name] = true
+			if hook.Image == "" {
+				return fmt.Errorf("spec.hooks.%s[%d].image is required", phase.name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Environment variables describing the launch, passed to the hooks
+func hookEnv(nfLaunch batchv1alpha1.NextflowLaunch, driver string, outcome string) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "LAUNCH_NAME", Value: nfLaunch.Name},
+		{Name: "LAUNCH_NAMESPACE", Value: nfLaunch.Namespace},
+		{Name: "LAUNCH_DIR", Value: nfLaunch.Spec.K8s["launchDir"]},
+		{Name: "WORK_DIR", Value: nfLaunch.Spec.K8s["workDir"]},
+		{Name: "DRIVER_POD", Value: driver},
+	}
+	if outcome != "" {
+		env = append(env, corev1.EnvVar{Name: "LAUNCH_STATUS", Value: outcome})
+	}
+	return env
+}
+
+// Construct the container of a hook; it gets the mounts and the security
+// settings of the driver container
+func hookContainer(hook batchv1alpha1.NextflowLaunchHook, driver corev1.Container, env []corev1.EnvVar) corev1.Container {
+	return corev1.Container{
+		Name:            hookContainerName(hook),
+		Image:           hook.Image,
+		Command:         hook.Command,
+		Args:            hook.Args,
+		Env:             append(append([]corev1.EnvVar{}, env...), hook.Env...),
+		Resources:       hook.Resources,
+		VolumeMounts:    append([]corev1.VolumeMount{}, driver.VolumeMounts...),
+		SecurityContext: driver.SecurityContext.DeepCopy(),
+	}
+}
+
+// Add the preRun hooks to the driver pod, as init containers
+func addPreRunHooks(pod *corev1.Pod, nfLaunch batchv1alpha1.NextflowLaunch) {
+	if nfLaunch.Spec.Hooks == nil {
+		return
+	}
+	env := hookEnv(nfLaunch, pod.Name, "")
+	for _, hook := range nfLaunch.Spec.Hooks.PreRun {
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, hookContainer(hook, pod.Spec.Containers[0], env))
+	}
+}
+
+// Construct the pod running a phase of hooks after the driver. It is based
+// on the driver pod, so it has the same volumes, placement and security
+// settings; the hooks are run in order, all but the last as init containers
+func makeHookPod(driver corev1.Pod, nfLaunch batchv1alpha1.NextflowLaunch, phase hookPhase, outcome string) corev1.Pod {
+	env := hookEnv(nfLaunch, driver.Name, outcome)
+	var containers []corev1.Container
+	for _, hook := range phase.hooks {
+		containers = append(containers, hookContainer(hook, driver.Spec.Containers[0], env))
+	}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        driver.Name + "-" + phase.suffix,
+			Namespace:   driver.Namespace,
+			Labels:      driver.Labels,
+			Annotations: driver.Annotations,
+		},
+		Spec: *driver.Spec.DeepCopy(),
+	}
+	pod.Spec.NodeName = ""
+	pod.Spec.InitContainers = containers[:len(containers)-1]
+	pod.Spec.Containers = containers[len(containers)-1:]
+	return pod
+}
+
+// Outcome of each hook of a phase, read from the statuses of the containers
+func hookStatuses(phase string, hooks []batchv1alpha1.NextflowLaunchHook, pod corev1.Pod) []batchv1alpha1.NextflowLaunchHookStatus {
+	states := map[string]corev1.ContainerState{}
+	for _, container := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		states[container.Name] = container.State
+	}
+	finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+	var statuses []batchv1alpha1.NextflowLaunchHookStatus
+	for _, hook := range hooks {
+		status := batchv1alpha1.NextflowLaunchHookStatus{Name: hook.Name, Phase: phase, Pod: pod.Name}
+		state, ok := states[hookContainerName(hook)]
+		switch {
+		case ok && state.Terminated != nil:
+			status.ExitCode = state.Terminated.ExitCode
+			status.State = hookSucceeded
+			if status.ExitCode != 0 {
+				status.State = hookFailed
+			}
+		case ok && state.Running != nil:
+			status.State = hookRunning
+		case finished:
+			// an earlier hook has failed
+			status.State = hookSkipped
+		default:
+			status.State = hookPending
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Replace the hook statuses of a phase; returns true if anything has changed
+func setHookStatuses(status *batchv1alpha1.NextflowLaunchStatus, phase string, statuses []batchv1alpha1.NextflowLaunchHookStatus) bool {
+	var kept, previous []batchv1alpha1.NextflowLaunchHookStatus
+	for _, hook := range status.Hooks {
+		if hook.Phase == phase {
+			previous = append(previous, hook)
+		} else {
+			kept = append(kept, hook)
+		}
+	}
+	if fmt.Sprint(previous) == fmt.Sprint(statuses) {
+		return false
+	}
+	status.Hooks = append(kept, statuses...)
+	return true
+}
+
+// Run the hooks following the driver, one phase after another.
+// Returns true when all of them have finished
+func (r *NextflowLaunchReconciler) runPostRunHooks(ctx context.Context, nfLaunch *batchv1alpha1.NextflowLaunch, driver corev1.Pod, outcome string) (bool, error) {
+	for _, phase := range postRunPhases(nfLaunch.Spec.Hooks, outcome) {
+		if len(phase.hooks) == 0 {
+			continue
+		}
+		var pod corev1.Pod
+		name := types.NamespacedName{Namespace: driver.Namespace, Name: driver.Name + "-" + phase.suffix}
+		err := r.Get(ctx, name, &pod)
+		if errors.IsNotFound(err) {
+			pod = makeHookPod(driver, *nfLaunch, phase, outcome)
+			ctrl.SetControllerReference(nfLaunch, &pod, r.Scheme)
+			err = r.Create(ctx, &pod)
+		}
+		if err != nil {
+			return false, err
+		}
+		// the statuses are saved by the caller
+		setHookStatuses(&nfLaunch.Status, phase.name, hookStatuses(phase.name, phase.hooks, pod))
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestHooks(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Hooks = &batchv1alpha1.NextflowLaunchHooks{
+			PreRun: []batchv1alpha1.NextflowLaunchHook{{Name: "stage", Image: "amazon/aws-cli"}},
+			OnSuccess: []batchv1alpha1.NextflowLaunchHook{
+				{Name: "publish", Image: "amazon/aws-cli"},
+				{Name: "notify", Image: "curlimages/curl"},
+			},
+			Always: []batchv1alpha1.NextflowLaunchHook{{Name: "cleanup", Image: "busybox"}},
+		}
+	})
+	driver, _ := makeNextflowPod(nfLaunch, "config")
+	if len(driver.Spec.InitContainers) != 1 || driver.Spec.InitContainers[0].Name != "hook-stage" {
+		t.Fatalf("unexpected init containers %+v", driver.Spec.InitContainers)
+	}
+
+	phases := postRunPhases(nfLaunch.Spec.Hooks, statusSucceeded)
+	pod := makeHookPod(driver, nfLaunch, phases[0], statusSucceeded)
+	if pod.Name != driver.Name+"-on-success" ||
+		len(pod.Spec.InitContainers) != 1 || pod.Spec.InitContainers[0].Name != "hook-publish" ||
+		len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Name != "hook-notify" {
+		t.Fatalf("unexpected hook pod %s: %+v %+v", pod.Name, pod.Spec.InitContainers, pod.Spec.Containers)
+	}
+	env := containerEnv(pod.Spec.Containers[0])
+	if env["LAUNCH_STATUS"] != statusSucceeded || env["DRIVER_POD"] != driver.Name || env["LAUNCH_DIR"] != nfLaunch.Spec.K8s["launchDir"] {
+		t.Errorf("unexpected hook environment %v", env)
+	}
+	if len(pod.Spec.Containers[0].VolumeMounts) != len(driver.Spec.Containers[0].VolumeMounts) {
+		t.Errorf("hook does not get the driver mounts: %+v", pod.Spec.Containers[0].VolumeMounts)
+	}
+
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "hook-publish",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2}},
+	}}
+	var status batchv1alpha1.NextflowLaunchStatus
+	if !setHookStatuses(&status, hookOnSuccess, hookStatuses(hookOnSuccess, phases[0].hooks, pod)) {
+		t.Fatal("hook statuses not recorded")
+	}
+	if status.Hooks[0].State != hookFailed || status.Hooks[0].ExitCode != 2 || status.Hooks[1].State != hookSkipped {
+		t.Errorf("unexpected hook statuses %+v", status.Hooks)
+	}
+
+	testRejected(t, specChanges{
+		"duplicated hook name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Hooks = &batchv1alpha1.NextflowLaunchHooks{
+				Always: []batchv1alpha1.NextflowLaunchHook{
+					{Name: "cleanup", Image: "busybox"},
+					{Name: "cleanup", Image: "busybox"},
+				},
+			}
+		},
+		"hook without an image": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Hooks = &batchv1alpha1.NextflowLaunchHooks{
+				Always: []batchv1alpha1.NextflowLaunchHook{{Name: "cleanup"}},
+			}
+		},
+	})
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	defaultNameTemplate = "{{ .Name }}-{{ .Index }}"
+
+	// the UID of the set, as its name may be too long for a label value
+	launchSetLabel      = "batch.mnm.bio/launch-set-uid"
+	launchSetIndexLabel = "batch.mnm.bio/launch-set-index"
+	// number of times a child has been relaunched, kept on the child so
+	// that it survives a failed update of the set status
+	retriesAnnotation = "batch.mnm.bio/retries"
+
+	// launch names are used as prefixes of the driver pod and container names
+	maxChildNameLength = validation.DNS1123LabelMaxLength - 9
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Validate launch set definition, return an error or nil
+func validateLaunchSet(nfLaunchSet batchv1alpha1.NextflowLaunchSet) error {
+
+	spec := nfLaunchSet.Spec
+
+	if spec.Samplesheet == nil && len(spec.Matrix) == 0 {
+		return errors.New("either spec.samplesheet or spec.matrix is required")
+	}
+	if spec.Samplesheet != nil && len(spec.Matrix) > 0 {
+		return errors.New("spec.samplesheet and spec.matrix are mutually exclusive")
+	}
+	if spec.Samplesheet != nil {
+		ref := spec.Samplesheet.ConfigMapKeyRef
+		if ref.Name == "" || ref.Key == "" {
+			return errors.New("spec.samplesheet.configMapKeyRef requires both name and key")
+		}
+		if len([]rune(spec.Samplesheet.Delimiter)) > 1 {
+			return errors.New("spec.samplesheet.delimiter must be a single character")
+		}
+	}
+	for key, values := range spec.Matrix {
+		if len(values) == 0 {
+			return fmt.Errorf("spec.matrix.%s has no values", key)
+		}
+	}
+	if spec.Parallelism < 0 {
+		return errors.New("spec.parallelism cannot be negative")
+	}
+	if spec.MaxRetries < 0 {
+		return errors.New("spec.maxRetries cannot be negative")
+	}
+	return nil
+}
+
+// Parse a samplesheet into rows of params, keyed by the header columns
+func parseSamplesheet(data string, key string, delimiter string) ([]map[string]string, error) {
+
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comma = ','
+	if strings.HasSuffix(key, ".tsv") {
+		reader.Comma = '\t'
+	}
+	if delimiter != "" {
+		reader.Comma = []rune(delimiter)[0]
+	}
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errors.New("samplesheet needs a header and at least one row")
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		if header[i] == "" {
+			return nil, fmt.Errorf("samplesheet column %d has no name", i+1)
+		}
+	}
+
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, value := range record {
+			row[header[i]] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Expand a parameter matrix into rows of params (cartesian product).
+// Keys are iterated in alphabetical order, so the result is deterministic
+func expandMatrix(matrix map[string][]string) []map[string]string {
+
+	keys := make([]string, 0, len(matrix))
+	for key := range matrix {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := []map[string]string{{}}
+	for _, key := range keys {
+		var expanded []map[string]string
+		for _, row := range rows {
+			for _, value := range matrix[key] {
+				next := map[string]string{key: value}
+				for k, v := range row {
+					next[k] = v
+				}
+				expanded = append(expanded, next)
+			}
+		}
+		rows = expanded
+	}
+	return rows
+}
+
+// Render the name of a child launch and make it a valid object name
+func childLaunchName(nameTemplate string, setName string, index int, params map[string]string) (string, error) {
+
+	if nameTemplate == "" {
+		nameTemplate = defaultNameTemplate
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return "", err
+	}
+	values := struct {
+		Name   string
+		Index  int
+		Params map[string]string
+	}{setName, index, params}
+
+	var name bytes.Buffer
+	err = tmpl.Execute(&name, values)
+	if err != nil {
+		return "", err
+	}
+
+	sanitized := invalidNameChars.ReplaceAllString(strings.ToLower(name.String()), "-")
+	sanitized = strings.Trim(sanitized, "-")
+	if len(sanitized) > maxChildNameLength {
+		sanitized = strings.TrimRight(sanitized[:maxChildNameLength], "-")
+	}
+	if errs := validation.IsDNS1123Label(sanitized); len(errs) > 0 {
+		return "", fmt.Errorf("invalid launch name %q: %s", sanitized, strings.Join(errs, ", "))
+	}
+	return sanitized, nil
+}
+
+// Construct a child launch from the launch set template
+func makeChildLaunch(nfLaunchSet batchv1alpha1.NextflowLaunchSet, name string, index int, params map[string]string) batchv1alpha1.NextflowLaunch {
+
+	spec := nfLaunchSet.Spec.Template.Spec.DeepCopy()
+	if spec.Params == nil {
+		spec.Params = map[string]string{}
+	}
+	for k, v := range params {
+		spec.Params[k] = v
+	}
+
+	labels := map[string]string{}
+	for k, v := range nfLaunchSet.Spec.Template.Labels {
+		labels[k] = v
+	}
+	labels[launchSetLabel] = string(nfLaunchSet.UID)
+	labels[launchSetIndexLabel] = strconv.Itoa(index)
+
+	return batchv1alpha1.NextflowLaunch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: nfLaunchSet.Namespace,
+			Labels:    labels,
+		},
+		Spec: *spec,
+	}
+}
+
+// Number of times a child launch has been relaunched
+func childRetries(child batchv1alpha1.NextflowLaunch) int32 {
+	retries, err := strconv.ParseInt(child.Annotations[retriesAnnotation], 10, 32)
+	if err != nil || retries < 0 {
+		return 0
+	}
+	return int32(retries)
+}
+
+// Record a relaunch of a child launch
+func countRetry(child *batchv1alpha1.NextflowLaunch) {
+	if child.Annotations == nil {
+		child.Annotations = map[string]string{}
+	}
+	child.Annotations[retriesAnnotation] = strconv.Itoa(int(childRetries(*child)) + 1)
+}
+
+// Children of a launch set that no longer match a row of its samplesheet
+// or matrix, in alphabetical order
+func staleChildren(existing map[string]*batchv1alpha1.NextflowLaunch, names []string) []string {
+	current := map[string]bool{}
+	for _, name := range names {
+		current[name] = true
+	}
+	var stale []string
+	for name := range existing {
+		if !current[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestParseSamplesheet(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		data      string
+		key       string
+		delimiter string
+		rows      []map[string]string
+		err       bool
+	}{
+		{
+			name: "csv",
+			data: "sample,fastq\nA,a.fq\nB, b.fq\n",
+			key:  "samples.csv",
+			rows: []map[string]string{{"sample": "A", "fastq": "a.fq"}, {"sample": "B", "fastq": "b.fq"}},
+		},
+		{
+			name: "tsv by key",
+			data: "sample\tfastq\nA\ta,1.fq\n",
+			key:  "samples.tsv",
+			rows: []map[string]string{{"sample": "A", "fastq": "a,1.fq"}},
+		},
+		{
+			name:      "explicit delimiter",
+			data:      "sample;fastq\nA;a.fq\n",
+			key:       "samples.tsv",
+			delimiter: ";",
+			rows:      []map[string]string{{"sample": "A", "fastq": "a.fq"}},
+		},
+		{name: "header only", data: "sample,fastq\n", key: "samples.csv", err: true},
+		{name: "unnamed column", data: "sample,\nA,a.fq\n", key: "samples.csv", err: true},
+		{name: "ragged row", data: "sample,fastq\nA\n", key: "samples.csv", err: true},
+	} {
+		rows, err := parseSamplesheet(c.data, c.key, c.delimiter)
+		switch {
+		case c.err && err == nil:
+			t.Errorf("%s: samplesheet was accepted: %v", c.name, rows)
+		case !c.err && err != nil:
+			t.Errorf("%s: %v", c.name, err)
+		case !c.err && !reflect.DeepEqual(rows, c.rows):
+			t.Errorf("%s: rows are %v, want %v", c.name, rows, c.rows)
+		}
+	}
+}
+
+func TestExpandMatrix(t *testing.T) {
+	for _, c := range []struct {
+		matrix map[string][]string
+		rows   []map[string]string
+	}{
+		{
+			matrix: map[string][]string{"genome": {"GRCh37", "GRCh38"}, "aligner": {"bwa", "bowtie2"}},
+			rows: []map[string]string{
+				{"aligner": "bwa", "genome": "GRCh37"},
+				{"aligner": "bwa", "genome": "GRCh38"},
+				{"aligner": "bowtie2", "genome": "GRCh37"},
+				{"aligner": "bowtie2", "genome": "GRCh38"},
+			},
+		},
+		{
+			matrix: map[string][]string{"genome": {"GRCh38"}},
+			rows:   []map[string]string{{"genome": "GRCh38"}},
+		},
+	} {
+		if rows := expandMatrix(c.matrix); !reflect.DeepEqual(rows, c.rows) {
+			t.Errorf("matrix %v expands to %v, want %v", c.matrix, rows, c.rows)
+		}
+	}
+}
+
+func TestChildLaunchName(t *testing.T) {
+	params := map[string]string{"sample": "Patient_01.B"}
+	for _, c := range []struct {
+		template string
+		setName  string
+		name     string
+		err      bool
+	}{
+		{"", "hello-set", "hello-set-3", false},
+		{"{{ .Name }}-{{ .Params.sample }}", "hello-set", "hello-set-patient-01-b", false},
+		{"--{{ .Params.sample }}--", "hello-set", "patient-01-b", false},
+		{"", strings.Repeat("a", 80), strings.Repeat("a", maxChildNameLength), false},
+		{"{{ .Name }}-{{ .Params.missing }}", "hello-set", "", true},
+		{"{{ .Name", "hello-set", "", true},
+		{"___", "hello-set", "", true},
+	} {
+		name, err := childLaunchName(c.template, c.setName, 3, params)
+		switch {
+		case c.err && err == nil:
+			t.Errorf("template %q was accepted: %q", c.template, name)
+		case !c.err && err != nil:
+			t.Errorf("template %q: %v", c.template, err)
+		case name != c.name:
+			t.Errorf("template %q renders %q, want %q", c.template, name, c.name)
+		}
+	}
+}
+
+func TestChildLaunch(t *testing.T) {
+	nfLaunchSet := batchv1alpha1.NextflowLaunchSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      strings.Repeat("a", 80),
+			Namespace: "default",
+			UID:       "6f1e1d4c-6e4b-4b4e-9a55-1d5e3c1b2a90",
+		},
+	}
+	nfLaunchSet.Spec.Template.Spec = testLaunch().Spec
+	child := makeChildLaunch(nfLaunchSet, "child", 2, map[string]string{"genome": "GRCh38"})
+	if child.Labels[launchSetLabel] != string(nfLaunchSet.UID) || child.Labels[launchSetIndexLabel] != "2" {
+		t.Errorf("unexpected labels %v", child.Labels)
+	}
+	if child.Spec.Params["genome"] != "GRCh38" {
+		t.Errorf("unexpected params %v", child.Spec.Params)
+	}
+
+	if retries := childRetries(child); retries != 0 {
+		t.Errorf("new child has %d retries", retries)
+	}
+	countRetry(&child)
+	countRetry(&child)
+	if retries := childRetries(child); retries != 2 || child.Annotations[retriesAnnotation] != "2" {
+		t.Errorf("child has %d retries (%v), want 2", retries, child.Annotations)
+	}
+
+	existing := map[string]*batchv1alpha1.NextflowLaunch{"set-a": nil, "set-b": nil, "set-c": nil}
+	if stale := staleChildren(existing, []string{"set-b", "set-d"}); !reflect.DeepEqual(stale, []string{"set-a", "set-c"}) {
+		t.Errorf("stale children are %v", stale)
+	}
+}
+
+func TestLaunchSetStage(t *testing.T) {
+	for _, c := range []struct {
+		status batchv1alpha1.NextflowLaunchSetStatus
+		want   string
+	}{
+		{batchv1alpha1.NextflowLaunchSetStatus{Total: 3, Succeeded: 2, Active: 1}, statusRunning},
+		{batchv1alpha1.NextflowLaunchSetStatus{Total: 3, Succeeded: 2, Failed: 1}, statusFailed},
+		{batchv1alpha1.NextflowLaunchSetStatus{Total: 3, DryRun: 3}, statusDryRun},
+		{batchv1alpha1.NextflowLaunchSetStatus{Total: 3, Succeeded: 1, DryRun: 2}, statusSucceeded},
+	} {
+		if got := launchSetStage(c.status); got != c.want {
+			t.Errorf("stage of %+v is %s, want %s", c.status, got, c.want)
+		}
+	}
+}
@@ -18,23 +18,32 @@ package controllers
 
 import (
 	"context"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/tower"
 )
 
 // NextflowLaunchReconciler reconciles a NextflowLaunch object
 type NextflowLaunchReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme                *runtime.Scheme
+	ExecutorLimits        ExecutorLimits
+	RestrictedPodSecurity bool
+	// plugin registry the pinned plugins are checked against
+	PluginsIndexURL string
 }
 
 //+kubebuilder:rbac:groups=batch.mnm.bio,resources=nextflowlaunches,verbs=get;list;watch;create;update;patch;delete
@@ -43,6 +52,13 @@ type NextflowLaunchReconciler struct {
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=pods/status,verbs=get
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 
 // Reconciler function for NextflowLaunch
 func (r *NextflowLaunchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -60,14 +76,25 @@ func (r *NextflowLaunchReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	nfLaunch, err = validateLaunch(nfLaunch)
+	if r.RestrictedPodSecurity && nfLaunch.Spec.Security == nil {
+		nfLaunch.Spec.Security = &batchv1alpha1.NextflowLaunchSecurity{Restricted: true}
+	}
+	// the limits apply to the definition as given, before the defaults
+	// (e.g. the generated command) are filled in
+	err = r.ExecutorLimits.enforce(&nfLaunch.Spec)
+	if err == nil {
+		nfLaunch, err = validateLaunch(nfLaunch)
+	}
+	stage := nfLaunch.Status.Stage
 	if err != nil {
 		log.Error(err, "Incorrect launch definition (yaml file)")
+		if stage != statusRunning && stage != statusSucceeded && stage != statusFailed && stage != statusInvalid {
+			nfLaunch.Status.Stage = statusInvalid
+			r.Status().Update(ctx, &nfLaunch)
+		}
 		return ctrl.Result{}, nil
 	}
 
-	stage := nfLaunch.Status.Stage
-
 	if stage == statusRunning {
 		// job is running, retrieve child pod to check status
 		var pod corev1.Pod
@@ -92,18 +119,69 @@ func (r *NextflowLaunchReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		status := pod.Status.Phase
 		log.Info("Job running (" + string(status) + ")")
 
+		// record the outcome of the pipeline pull
+		if nfLaunch.Spec.Pipeline.Prefetch && updatePrefetchStatus(&nfLaunch.Status, pod) {
+			condition := meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionPipelineFetched)
+			log.Info(condition.Reason + ": " + condition.Message)
+			r.Status().Update(ctx, &nfLaunch)
+		}
+
+		// record the outcome of the offline check
+		if nfLaunch.Spec.Offline != nil && updateOfflineStatus(&nfLaunch.Status, pod) {
+			condition := meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionOfflineReady)
+			log.Info(condition.Reason + ": " + condition.Message)
+			r.Status().Update(ctx, &nfLaunch)
+		}
+
 		// pod running? mark as successful launch
 		if (!nfLaunch.Status.Launched) && (status == corev1.PodRunning) {
 			nfLaunch.Status.Launched = true
 			r.Status().Update(ctx, &nfLaunch)
 		}
 
+		// find the run in Seqera Platform
+		if nfLaunch.Status.Tower != nil && nfLaunch.Status.Tower.RunURL == "" && status != corev1.PodPending {
+			err = r.updateTowerStatus(ctx, &nfLaunch)
+			if err == nil {
+				log.Info("Run available at " + nfLaunch.Status.Tower.RunURL)
+				r.Status().Update(ctx, &nfLaunch)
+			} else if err != tower.ErrNotFound {
+				log.Error(err, "Error querying Seqera Platform")
+			}
+		}
+
+		// record the outcome of the preRun hooks
+		if hooks := nfLaunch.Spec.Hooks; hooks != nil && len(hooks.PreRun) > 0 {
+			if setHookStatuses(&nfLaunch.Status, hookPreRun, hookStatuses(hookPreRun, hooks.PreRun, pod)) {
+				r.Status().Update(ctx, &nfLaunch)
+			}
+		}
+
+		// run the post-run hooks before marking the launch as finished
+		if status == corev1.PodSucceeded || status == corev1.PodFailed {
+			outcome := statusSucceeded
+			if status == corev1.PodFailed {
+				outcome = statusFailed
+			}
+			done, err := r.runPostRunHooks(ctx, &nfLaunch, pod, outcome)
+			if err != nil {
+				log.Error(err, "Error running post-run hooks")
+				return ctrl.Result{}, err
+			}
+			if !done {
+				r.Status().Update(ctx, &nfLaunch)
+				return ctrl.Result{RequeueAfter: 3e+9}, nil
+			}
+		}
+
 		if status == corev1.PodSucceeded {
 			nfLaunch.Status.Stage = statusSucceeded
+			nfLaunch.Status.Artifacts = reportArtifacts(nfLaunch.Spec)
 			r.Status().Update(ctx, &nfLaunch)
 
 		} else if status == corev1.PodFailed {
 			nfLaunch.Status.Stage = statusFailed
+			nfLaunch.Status.Artifacts = reportArtifacts(nfLaunch.Spec)
 			r.Status().Update(ctx, &nfLaunch)
 
 		} else {
@@ -120,18 +198,147 @@ func (r *NextflowLaunchReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		log.Info("Job failed! Use `kubectl logs " + nfLaunch.Status.MainPod.Name +
 			"` to diagnose")
 
+	} else if isDryRun(nfLaunch) {
+		// only render what would be created, for review
+		if nfLaunch.Status.DryRun != nil && nfLaunch.Status.DryRun.ObservedGeneration == nfLaunch.Generation {
+			return ctrl.Result{}, nil
+		}
+		if condition, err := r.preflight(ctx, &nfLaunch); err != nil {
+			return ctrl.Result{}, err
+		} else if condition != nil {
+			log.Info(condition.Reason + ": " + condition.Message)
+			nfLaunch.Status.Stage = statusInvalid
+			r.Status().Update(ctx, &nfLaunch)
+			return ctrl.Result{}, nil
+		}
+		dryRun, err := makeDryRun(nfLaunch)
+		if err != nil {
+			log.Error(err, "Error rendering Nextflow config")
+			return ctrl.Result{}, nil
+		}
+		log.Info("Dry run, the driver pod will not be created")
+		nfLaunch.Status.DryRun = dryRun
+		nfLaunch.Status.Stage = statusDryRun
+		r.Status().Update(ctx, &nfLaunch)
+
 	} else {
-		// job is ready to run, create children
-		configMap := makeNextflowConfig(nfLaunch)
-		ctrl.SetControllerReference(&nfLaunch, &configMap, r.Scheme)
-		err = r.Client.Create(ctx, &configMap)
+		// job is ready to run, check the params and the plugins before
+		// anything is created
+		if condition, err := r.preflight(ctx, &nfLaunch); err != nil {
+			return ctrl.Result{}, err
+		} else if condition != nil {
+			log.Info(condition.Reason + ": " + condition.Message)
+			nfLaunch.Status.Stage = statusInvalid
+			r.Status().Update(ctx, &nfLaunch)
+			return ctrl.Result{}, nil
+		}
+
+		// create children
+		nfLaunch.Status.DryRun = nil
+		nfLaunch.Status.Hooks = nil
+		if account := nfLaunch.Spec.ServiceAccount; account != nil && account.Create {
+			err = r.createServiceAccount(ctx, nfLaunch)
+			if isNotOwned(err) {
+				// the object may be removed or renamed later, check again in a while
+				log.Info("Cannot create service account " + account.Name + ": " + err.Error())
+				nfLaunch.Status.Stage = statusInvalid
+				r.Status().Update(ctx, &nfLaunch)
+				return ctrl.Result{RequeueAfter: 60e+9}, nil
+			}
+			if err != nil {
+				log.Error(err, "Error creating service account "+account.Name)
+				return ctrl.Result{}, err
+			}
+		} else if account != nil {
+			missing, err := r.missingPermissions(ctx, nfLaunch)
+			if err != nil {
+				log.Error(err, "Error checking permissions of service account "+account.Name)
+				return ctrl.Result{}, err
+			}
+			if len(missing) > 0 {
+				// permissions may be granted later, check again in a while
+				log.Info("Service account " + account.Name + " cannot: " + strings.Join(missing, ", "))
+				nfLaunch.Status.MissingPermissions = missing
+				nfLaunch.Status.Stage = statusInvalid
+				r.Status().Update(ctx, &nfLaunch)
+				return ctrl.Result{RequeueAfter: 60e+9}, nil
+			}
+		}
+		nfLaunch.Status.MissingPermissions = nil
+		if len(nfLaunch.Spec.Secrets) > 0 {
+			values, err := r.fetchSecretValues(ctx, nfLaunch)
+			if err != nil {
+				log.Error(err, "Error reading secrets referenced by the launch")
+				return ctrl.Result{}, err
+			}
+			store, err := makeSecretsStore(nfLaunch, values)
+			if err != nil {
+				log.Error(err, "Error creating Nextflow secrets")
+				return ctrl.Result{}, err
+			}
+			secret := corev1.Secret{ObjectMeta: store.ObjectMeta}
+			_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &secret, func() error {
+				secret.Type = store.Type
+				secret.Data = store.Data
+				return ctrl.SetControllerReference(&nfLaunch, &secret, r.Scheme)
+			})
+			if err != nil {
+				log.Error(err, "Error creating Nextflow secrets")
+				return ctrl.Result{}, err
+			}
+			nfLaunch.Status.Secret, _ = reference.GetReference(r.Scheme, &secret)
+		}
+
+		if credentials := nfLaunch.Spec.Pipeline.Credentials; credentials != nil && credentials.BasicAuthSecretName != "" {
+			err = r.createScmSecret(ctx, nfLaunch)
+			if err != nil {
+				log.Error(err, "Error creating SCM config from secret "+credentials.BasicAuthSecretName)
+				return ctrl.Result{}, err
+			}
+		} else if credentials != nil && credentials.SSHSecretName != "" {
+			err = r.checkSSHKey(ctx, nfLaunch)
+			if err != nil && !errors.IsNotFound(err) && !isMissingKey(err) {
+				log.Error(err, "Error reading ssh secret "+credentials.SSHSecretName)
+				return ctrl.Result{}, err
+			}
+			if err != nil {
+				// the secret may be created or fixed later, check again in a while
+				log.Info("Invalid ssh secret " + credentials.SSHSecretName + ": " + err.Error())
+				nfLaunch.Status.Stage = statusInvalid
+				r.Status().Update(ctx, &nfLaunch)
+				return ctrl.Result{RequeueAfter: 60e+9}, nil
+			}
+		}
+
+		configMap, err := makeNextflowConfig(nfLaunch)
+		if err != nil {
+			// already rendered in validateLaunch, retrying would not help
+			log.Error(err, "Error rendering Nextflow config")
+			return ctrl.Result{}, nil
+		}
+		current := corev1.ConfigMap{ObjectMeta: configMap.ObjectMeta}
+		_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &current, func() error {
+			current.Labels = configMap.Labels
+			current.Data = configMap.Data
+			return ctrl.SetControllerReference(&nfLaunch, &current, r.Scheme)
+		})
 		if err != nil {
 			log.Error(err, "Error creating Nextflow config")
 			return ctrl.Result{}, err
 		}
-		nfLaunch.Status.ConfigMap, _ = reference.GetReference(r.Scheme, &configMap)
+		nfLaunch.Status.ConfigMap, _ = reference.GetReference(r.Scheme, &current)
+
+		if nfLaunch.Spec.Tower != nil {
+			nfLaunch.Status.Tower = &batchv1alpha1.NextflowLaunchTowerStatus{
+				RunName: towerRunName(nfLaunch),
+			}
+		}
 
-		pod := makeNextflowPod(nfLaunch, configMap.Name)
+		pod, err := makeNextflowPod(nfLaunch, configMap.Name)
+		if err != nil {
+			log.Error(err, "Incorrect launch definition (yaml file)")
+			return ctrl.Result{}, nil
+		}
 		ctrl.SetControllerReference(&nfLaunch, &pod, r.Scheme)
 		log.Info("Starting pod " + pod.Name)
 		err = r.Client.Create(ctx, &pod)
@@ -140,6 +347,12 @@ func (r *NextflowLaunchReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			return ctrl.Result{}, err
 		}
 		nfLaunch.Status.MainPod, _ = reference.GetReference(r.Scheme, &pod)
+		recordAttempt(&nfLaunch.Status, pod, configMap)
+
+		err = r.pruneConfigMaps(ctx, nfLaunch, configMap.Name)
+		if err != nil {
+			log.Error(err, "Error removing stale Nextflow configs")
+		}
 
 		nfLaunch.Status.Stage = statusRunning
 		r.Status().Update(ctx, &nfLaunch)
@@ -153,3 +366,30 @@ func (r *NextflowLaunchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&batchv1alpha1.NextflowLaunch{}).
 		Complete(r)
 }
+
+// Checks that need the network or other objects, run once per generation
+// before the driver is created; returns the first failed condition
+func (r *NextflowLaunchReconciler) preflight(ctx context.Context, nfLaunch *batchv1alpha1.NextflowLaunch) (*metav1.Condition, error) {
+	log := log.FromContext(ctx)
+	if nfLaunch.Spec.ParamsSchema != nil {
+		condition, err := r.checkLaunchParams(ctx, nfLaunch)
+		if err != nil {
+			log.Error(err, "Error fetching the pipeline schema")
+			return nil, err
+		}
+		if condition.Status != metav1.ConditionTrue {
+			return condition, nil
+		}
+	}
+	if hasRegistryPins(nfLaunch.Spec) {
+		condition, err := r.checkLaunchPlugins(ctx, nfLaunch)
+		if err != nil {
+			log.Error(err, "Error reading the plugin registry")
+			return nil, err
+		}
+		if condition.Status != metav1.ConditionTrue {
+			return condition, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+// NextflowLaunchSetReconciler reconciles a NextflowLaunchSet object
+type NextflowLaunchSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=batch.mnm.bio,resources=nextflowlaunchsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch.mnm.bio,resources=nextflowlaunchsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch.mnm.bio,resources=nextflowlaunchsets/finalizers,verbs=update
+
+// Reconciler function for NextflowLaunchSet
+func (r *NextflowLaunchSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	log := log.FromContext(ctx)
+	var nfLaunchSet batchv1alpha1.NextflowLaunchSet
+
+	err := r.Get(ctx, req.NamespacedName, &nfLaunchSet)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("Nextflow launch set " + req.Name + " deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Error fetching Nextflow launch set "+req.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	err = validateLaunchSet(nfLaunchSet)
+	if err != nil {
+		log.Error(err, "Incorrect launch set definition (yaml file)")
+		return ctrl.Result{}, nil
+	}
+
+	rows, err := r.launchSetRows(ctx, nfLaunchSet)
+	if err != nil {
+		log.Error(err, "Error reading launch set parameters")
+		return ctrl.Result{}, err
+	}
+
+	// names of the children, in the order of the rows
+	names := make([]string, len(rows))
+	seen := map[string]bool{}
+	for i, row := range rows {
+		names[i], err = childLaunchName(nfLaunchSet.Spec.NameTemplate, nfLaunchSet.Name, i, row)
+		if err != nil {
+			log.Error(err, "Incorrect launch set name template")
+			return ctrl.Result{}, nil
+		}
+		if seen[names[i]] {
+			log.Error(fmt.Errorf("launch name %q is not unique", names[i]), "Incorrect launch set name template")
+			return ctrl.Result{}, nil
+		}
+		seen[names[i]] = true
+	}
+
+	var children batchv1alpha1.NextflowLaunchList
+	err = r.List(ctx, &children,
+		client.InNamespace(nfLaunchSet.Namespace),
+		client.MatchingLabels{launchSetLabel: string(nfLaunchSet.UID)})
+	if err != nil {
+		log.Error(err, "Error listing child launches")
+		return ctrl.Result{}, err
+	}
+	existing := map[string]*batchv1alpha1.NextflowLaunch{}
+	for i := range children.Items {
+		if metav1.IsControlledBy(&children.Items[i], &nfLaunchSet) {
+			existing[children.Items[i].Name] = &children.Items[i]
+		}
+	}
+
+	// delete the children whose row was removed
+	for _, name := range staleChildren(existing, names) {
+		log.Info("Deleting launch " + name + ", removed from the launch set")
+		err = r.Delete(ctx, existing[name], client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Error deleting launch "+name)
+			return ctrl.Result{}, err
+		}
+		delete(existing, name)
+	}
+
+	status := batchv1alpha1.NextflowLaunchSetStatus{Total: int32(len(rows))}
+
+	// tally the existing children, relaunching the failed ones if allowed;
+	// the retry is counted on the child before it is relaunched
+	for _, name := range names {
+		child, ok := existing[name]
+		if !ok {
+			continue
+		}
+		stage := child.Status.Stage
+		if stage == statusFailed && childRetries(*child) < nfLaunchSet.Spec.MaxRetries {
+			countRetry(child)
+			err = r.Update(ctx, child)
+			if err != nil {
+				log.Error(err, "Error counting the retry of "+name)
+				return ctrl.Result{}, err
+			}
+			child.Status.Stage = statusRelaunch
+			child.Status.Launched = false
+			err = r.Status().Update(ctx, child)
+			if err != nil {
+				log.Error(err, "Error relaunching "+name)
+				return ctrl.Result{}, err
+			}
+			stage = statusRelaunch
+			log.Info(fmt.Sprintf("Relaunching %s (retry %d)", name, childRetries(*child)))
+		}
+		switch stage {
+		case statusSucceeded:
+			status.Succeeded++
+		case statusFailed, statusInvalid:
+			status.Failed++
+		case statusDryRun:
+			// rendered only, does not occupy a parallelism slot
+			status.DryRun++
+		default:
+			status.Active++
+		}
+	}
+
+	// create missing children, as long as parallelism allows
+	for i, name := range names {
+		if _, ok := existing[name]; ok {
+			continue
+		}
+		if nfLaunchSet.Spec.Parallelism > 0 && status.Active >= nfLaunchSet.Spec.Parallelism {
+			break
+		}
+		child := makeChildLaunch(nfLaunchSet, name, i, rows[i])
+		ctrl.SetControllerReference(&nfLaunchSet, &child, r.Scheme)
+		log.Info("Creating launch " + name)
+		err = r.Create(ctx, &child)
+		if err != nil {
+			log.Error(err, "Error creating launch "+name)
+			return ctrl.Result{}, err
+		}
+		existing[name] = &child
+		status.Active++
+	}
+
+	for _, name := range names {
+		child := batchv1alpha1.NextflowLaunchSetChild{Name: name}
+		if launch, ok := existing[name]; ok {
+			child.Stage = launch.Status.Stage
+			child.Retries = childRetries(*launch)
+		}
+		status.Children = append(status.Children, child)
+	}
+	status.Stage = launchSetStage(status)
+	log.Info(fmt.Sprintf("Launch set %s: %d/%d succeeded, %d failed, %d active",
+		status.Stage, status.Succeeded, status.Total, status.Failed, status.Active))
+
+	nfLaunchSet.Status = status
+	err = r.Status().Update(ctx, &nfLaunchSet)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// Aggregate stage of a launch set; dry-run and invalid children are
+// finished, as they are never going to run
+func launchSetStage(status batchv1alpha1.NextflowLaunchSetStatus) string {
+	switch {
+	case status.Succeeded+status.Failed+status.DryRun < status.Total:
+		return statusRunning
+	case status.Failed > 0:
+		return statusFailed
+	case status.DryRun == status.Total:
+		return statusDryRun
+	default:
+		return statusSucceeded
+	}
+}
+
+// Retrieve the params of each child launch, from a samplesheet or a matrix
+func (r *NextflowLaunchSetReconciler) launchSetRows(ctx context.Context, nfLaunchSet batchv1alpha1.NextflowLaunchSet) ([]map[string]string, error) {
+
+	samplesheet := nfLaunchSet.Spec.Samplesheet
+	if samplesheet == nil {
+		return expandMatrix(nfLaunchSet.Spec.Matrix), nil
+	}
+
+	var configMap corev1.ConfigMap
+	name := types.NamespacedName{
+		Namespace: nfLaunchSet.Namespace,
+		Name:      samplesheet.ConfigMapKeyRef.Name,
+	}
+	err := r.Get(ctx, name, &configMap)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := configMap.Data[samplesheet.ConfigMapKeyRef.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in config map %q",
+			samplesheet.ConfigMapKeyRef.Key, samplesheet.ConfigMapKeyRef.Name)
+	}
+	return parseSamplesheet(data, samplesheet.ConfigMapKeyRef.Key, samplesheet.Delimiter)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NextflowLaunchSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&batchv1alpha1.NextflowLaunchSet{}).
+		Owns(&batchv1alpha1.NextflowLaunch{}).
+		Complete(r)
+}
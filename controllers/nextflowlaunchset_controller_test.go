@@ -0,0 +1,114 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("NextflowLaunchSet controller", func() {
+
+	Context("When creating a NextflowLaunchSet object with a matrix", func() {
+
+		It("Should spawn a NextflowLaunch per combination", func() {
+
+			///
+			By("Creating a NextflowLaunchSet object")
+			ctx := context.Background()
+			nfLaunchSet := &batchv1alpha1.NextflowLaunchSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-launch-set",
+					Namespace: "default",
+				},
+				Spec: batchv1alpha1.NextflowLaunchSetSpec{
+					Template: batchv1alpha1.NextflowLaunchTemplate{
+						Spec: batchv1alpha1.NextflowLaunchSpec{
+							Pipeline: batchv1alpha1.NextflowLaunchPipeline{
+								Source: "hello",
+							},
+							K8s: map[string]string{
+								"storageClaimName": "test-pvc",
+							},
+						},
+					},
+					Matrix: map[string][]string{
+						"genome":  {"GRCh37", "GRCh38"},
+						"aligner": {"bwa"},
+					},
+					NameTemplate: "{{ .Name }}-{{ .Params.genome }}",
+				},
+			}
+			Expect(k8sClient.Create(ctx, nfLaunchSet)).Should(Succeed())
+
+			///
+			By("Listing the child launches")
+			children := &batchv1alpha1.NextflowLaunchList{}
+			Eventually(func() int {
+				err := k8sClient.List(ctx, children,
+					client.InNamespace("default"),
+					client.MatchingLabels{launchSetLabel: string(nfLaunchSet.UID)})
+				if err != nil {
+					return 0
+				}
+				return len(children.Items)
+			}, 10*time.Second, time.Second).Should(Equal(2))
+
+			///
+			By("Checking the params of a child launch")
+			lookupKey := types.NamespacedName{
+				Name:      "test-launch-set-grch38",
+				Namespace: "default",
+			}
+			child := &batchv1alpha1.NextflowLaunch{}
+			Expect(k8sClient.Get(ctx, lookupKey, child)).Should(Succeed())
+			Expect(child.Spec.Params).To(HaveKeyWithValue("genome", "GRCh38"))
+			Expect(child.Spec.Params).To(HaveKeyWithValue("aligner", "bwa"))
+
+			///
+			By("Removing a value from the matrix")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(nfLaunchSet), nfLaunchSet)).Should(Succeed())
+			nfLaunchSet.Spec.Matrix["genome"] = []string{"GRCh38"}
+			Expect(k8sClient.Update(ctx, nfLaunchSet)).Should(Succeed())
+			Eventually(func() bool {
+				removed := &batchv1alpha1.NextflowLaunch{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-launch-set-grch37", Namespace: "default"}, removed)
+				return err != nil || removed.DeletionTimestamp != nil
+			}, 10*time.Second, time.Second).Should(BeTrue())
+
+			///
+			By("Checking the aggregated status")
+			lookupKey = types.NamespacedName{
+				Name:      "test-launch-set",
+				Namespace: "default",
+			}
+			testLaunchSet := &batchv1alpha1.NextflowLaunchSet{}
+			Eventually(func() int32 {
+				k8sClient.Get(ctx, lookupKey, testLaunchSet)
+				return testLaunchSet.Status.Total
+			}, 10*time.Second, time.Second).Should(Equal(int32(1)))
+		})
+	})
+})
@@ -0,0 +1,178 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// name of the init container checking the offline assets
+	offlineContainerName = "nextflow-offline-check"
+	// condition reporting the outcome of the check
+	conditionOfflineReady = "OfflineAssetsAvailable"
+)
+
+// Revisions that are (abbreviated) commit hashes rather than branches or tags
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Check the offline settings of a launch definition and fill in the
+// Nextflow home
+func validateOffline(spec *batchv1alpha1.NextflowLaunchSpec) error {
+	if spec.Offline == nil {
+		return nil
+	}
+	offline := spec.Offline.DeepCopy()
+	if offline.Home == "" {
+		offline.Home = spec.K8s["storageMountPath"] + "/.nextflow"
+	}
+	if !path.IsAbs(offline.Home) || !onVolume(*spec, offline.Home) {
+		return errors.New("spec.offline.home must be an absolute path on the storage volume or one of spec.volumes")
+	}
+	if spec.Pipeline.Prefetch {
+		return errors.New("spec.offline cannot be combined with spec.pipeline.prefetch")
+	}
+	if spec.Nextflow.Home != "" && path.Clean(spec.Nextflow.Home) != path.Clean(offline.Home) {
+		return errors.New("spec.nextflow.home differs from spec.offline.home")
+	}
+	if spec.Pipeline.Source != "" {
+		if _, err := projectName(spec.Pipeline.Source); err != nil {
+			return fmt.Errorf("spec.pipeline.source: %w", err)
+		}
+	}
+	spec.Offline = offline
+	spec.Nextflow.Home = offline.Home
+	return nil
+}
+
+// Shell script checking that everything the driver needs (the runtime, the
+// pipeline and the plugins, which must support the runtime) is in the
+// Nextflow home; the reason of a failure goes to the termination message
+func offlineScript(spec batchv1alpha1.NextflowLaunchSpec) string {
+	lines := []string{
+		`fail() { echo "$1" > /dev/termination-log; echo "$1" >&2; exit 1; }`,
+		`[ -d "$NXF_HOME"/framework/` + shellQuote(spec.Nextflow.Version) + ` ] || fail ` +
+			shellQuote("Nextflow "+spec.Nextflow.Version+" not found in "+spec.Offline.Home+"/framework"),
+	}
+	if spec.Pipeline.Source != "" {
+		project, _ := projectName(spec.Pipeline.Source)
+		lines = append(lines,
+			`git="$NXF_ASSETS"/`+shellQuote(project)+"/.git",
+			`[ -d "$git" ] || fail `+shellQuote("Pipeline "+project+" not found in "+spec.Offline.Home+"/assets"),
+		)
+		if revision := spec.Pipeline.Revision; revision != "" && !commitPattern.MatchString(revision) {
+			// Nextflow keeps branches as remote refs
+			refs := []string{"refs/tags/" + revision, "refs/heads/" + revision, "refs/remotes/origin/" + revision}
+			var checks []string
+			for _, ref := range refs {
+				checks = append(checks, `[ -f "$git"/`+shellQuote(ref)+` ]`)
+			}
+			lines = append(lines, strings.Join(checks, " || ")+
+				` || cut -d" " -f2 "$git/packed-refs" 2>/dev/null | grep -qxF`+
+				` -e `+shellQuote(refs[0])+` -e `+shellQuote(refs[1])+` -e `+shellQuote(refs[2])+
+				` || fail `+shellQuote("Revision "+revision+" of "+project+" not found in "+spec.Offline.Home+"/assets"))
+		}
+	}
+	if len(spec.Nextflow.Plugins) > 0 {
+		// Nextflow release required by a plugin, from its manifest; at_least
+		// compares the numeric parts of two versions
+		lines = append(lines,
+			`requires() { sed -n 's/^Plugin-Requires: *>= *\([0-9][0-9.]*\).*/\1/p' "$NXF_PLUGINS_DIR/$1/classes/META-INF/MANIFEST.MF" 2>/dev/null | head -n1; }`,
+			`at_least() { awk -v a="$1" -v b="$2" 'BEGIN { n = split(a, x, "."); m = split(b, y, "."); `+
+				`for (i = 1; i <= (n > m ? n : m); i++) { if (x[i] + 0 < y[i] + 0) exit 1; if (x[i] + 0 > y[i] + 0) exit 0 } exit 0 }'; }`,
+		)
+	}
+	for _, plugin := range spec.Nextflow.Plugins {
+		dir := shellQuote(pluginDir(plugin))
+		lines = append(lines,
+			`[ -d "$NXF_PLUGINS_DIR"/`+dir+` ] || fail `+
+				shellQuote("Plugin "+plugin+" not found in "+spec.Offline.Home+"/plugins"),
+			`required=$(requires `+dir+`)`,
+			`[ -n "$required" ] || fail `+
+				shellQuote("Nextflow requirement of plugin "+plugin+" not found in its manifest"),
+			`at_least `+shellQuote(spec.Nextflow.Version)+` "$required" || fail `+
+				shellQuote("Plugin "+plugin+" requires Nextflow ")+`"$required"`+
+				shellQuote(" or later, not "+spec.Nextflow.Version),
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Point the driver to the offline Nextflow home and add the init container
+// checking it; the init container shares the environment, the mounts and
+// the security settings of the driver
+func addOfflineCheck(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	driver := &pod.Spec.Containers[0]
+	driver.Env = append(driver.Env,
+		corev1.EnvVar{Name: "NXF_OFFLINE", Value: "true"},
+		corev1.EnvVar{Name: "NXF_ASSETS", Value: spec.Offline.Home + "/assets"},
+		corev1.EnvVar{Name: "NXF_PLUGINS_DIR", Value: spec.Offline.Home + "/plugins"},
+	)
+
+	init := *driver.DeepCopy()
+	init.Name = offlineContainerName
+	init.Command = []string{"sh", "-c", offlineScript(spec)}
+	init.Args = nil
+	init.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, init)
+}
+
+// Termination state of an init container, if it has finished
+func initContainerTerminated(pod corev1.Pod, name string) *corev1.ContainerStateTerminated {
+	for _, container := range pod.Status.InitContainerStatuses {
+		if container.Name == name {
+			return container.State.Terminated
+		}
+	}
+	return nil
+}
+
+// Record the outcome of the offline check in the status of a launch;
+// returns true if the status has changed
+func updateOfflineStatus(status *batchv1alpha1.NextflowLaunchStatus, pod corev1.Pod) bool {
+	terminated := initContainerTerminated(pod, offlineContainerName)
+	if terminated == nil {
+		return false
+	}
+	condition := metav1.Condition{
+		Type:    conditionOfflineReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Available",
+		Message: "Offline assets available",
+	}
+	if terminated.ExitCode != 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "AssetsMissing"
+		condition.Message = strings.TrimSpace(terminated.Message)
+	}
+	existing := meta.FindStatusCondition(status.Conditions, conditionOfflineReady)
+	if existing != nil && existing.Status == condition.Status && existing.Message == condition.Message {
+		return false
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return true
+}
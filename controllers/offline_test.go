@@ -0,0 +1,79 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestOffline(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Offline = &batchv1alpha1.NextflowLaunchOffline{}
+		spec.Pipeline.Revision = "v1.1"
+	})
+	home := defaultMountPath + "/.nextflow"
+	if nfLaunch.Spec.Nextflow.Home != home {
+		t.Errorf("Nextflow home is %q, want %q", nfLaunch.Spec.Nextflow.Home, home)
+	}
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	if len(pod.Spec.InitContainers) != 1 || pod.Spec.InitContainers[0].Name != offlineContainerName {
+		t.Fatalf("unexpected init containers %+v", pod.Spec.InitContainers)
+	}
+	env := containerEnv(pod.Spec.Containers[0])
+	if env["NXF_OFFLINE"] != "true" || env["NXF_HOME"] != home || env["NXF_ASSETS"] != home+"/assets" || env["NXF_PLUGINS_DIR"] != home+"/plugins" {
+		t.Errorf("unexpected driver environment %v", env)
+	}
+	script := pod.Spec.InitContainers[0].Command[2]
+	if !strings.Contains(script, "refs/remotes/origin/v1.1") {
+		t.Errorf("revision not checked:\n%s", script)
+	}
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name: offlineContainerName,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1,
+			Message:  "Revision v1.1 of nextflow-io/hello not found in " + home + "/assets\n",
+		}},
+	}}
+	var status batchv1alpha1.NextflowLaunchStatus
+	if !updateOfflineStatus(&status, pod) || status.Conditions[0].Reason != "AssetsMissing" ||
+		!strings.HasPrefix(status.Conditions[0].Message, "Revision v1.1") {
+		t.Errorf("missing assets not reported: %+v", status.Conditions)
+	}
+
+	testRejected(t, specChanges{
+		"offline home outside the storage volume": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Offline = &batchv1alpha1.NextflowLaunchOffline{Home: "/opt/nextflow"}
+		},
+		"relative offline home": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Offline = &batchv1alpha1.NextflowLaunchOffline{Home: "relative"}
+		},
+		"offline launch with prefetch": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Offline = &batchv1alpha1.NextflowLaunchOffline{}
+			spec.Pipeline.Prefetch = true
+		},
+		"unpinned plugin in offline mode": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Offline = &batchv1alpha1.NextflowLaunchOffline{}
+			spec.Nextflow.Plugins = []string{"nf-prov"}
+		},
+	})
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/nfschema"
+)
+
+const (
+	// Upper limit on the size of a downloaded pipeline schema
+	maxParamsSchemaSize = 1 << 20
+	// condition reporting the outcome of the params check
+	conditionParamsValid = "ParamsValid"
+)
+
+// Check the paramsSchema section of a launch definition
+func validateParamsSchema(schema *batchv1alpha1.NextflowLaunchParamsSchema) error {
+	if schema == nil {
+		return nil
+	}
+	if (schema.ConfigMapKeyRef == nil) == (schema.URL == "") {
+		return errors.New("spec.paramsSchema requires exactly one of configMapKeyRef and url")
+	}
+	if schema.ConfigMapKeyRef != nil && (schema.ConfigMapKeyRef.Name == "" || schema.ConfigMapKeyRef.Key == "") {
+		return errors.New("spec.paramsSchema.configMapKeyRef requires name and key")
+	}
+	if schema.URL != "" {
+		u, err := url.Parse(schema.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("spec.paramsSchema.url %q is not a valid http(s) URL", schema.URL)
+		}
+		if isInternalHost(u.Hostname()) {
+			return fmt.Errorf("spec.paramsSchema.url cannot point to %s inside the cluster; use configMapKeyRef instead", u.Hostname())
+		}
+	}
+	return nil
+}
+
+// Validate the params against a pipeline schema and apply its defaults
+func checkParams(params map[string]string, data []byte) (*batchv1alpha1.NextflowLaunchParamsStatus, error) {
+	schema, err := nfschema.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	effective, errs := schema.Validate(params)
+	status := batchv1alpha1.NextflowLaunchParamsStatus{Effective: effective}
+	for _, err := range errs {
+		status.Errors = append(status.Errors, "params."+err.Error())
+	}
+	return &status, nil
+}
+
+// Record the outcome of the params check in the status of a launch,
+// for the current generation of its definition. The status is readable by
+// other users, so schema errors are only reported in general terms
+func setParamsStatus(nfLaunch *batchv1alpha1.NextflowLaunch, params *batchv1alpha1.NextflowLaunchParamsStatus, err error) {
+	condition := metav1.Condition{
+		Type:               conditionParamsValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: nfLaunch.Generation,
+		Reason:             "Valid",
+		Message:            "Params match the pipeline schema",
+	}
+	switch {
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSchema"
+		condition.Message = "The pipeline schema is not a valid JSON schema"
+	case len(params.Errors) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidParams"
+		condition.Message = strings.Join(params.Errors, "; ")
+	}
+	nfLaunch.Status.Params = params
+	meta.SetStatusCondition(&nfLaunch.Status.Conditions, condition)
+}
+
+// Check the params of a launch against its pipeline schema, unless they
+// have already been checked for the current generation; returns the
+// ParamsValid condition
+func (r *NextflowLaunchReconciler) checkLaunchParams(ctx context.Context, nfLaunch *batchv1alpha1.NextflowLaunch) (*metav1.Condition, error) {
+	condition := meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionParamsValid)
+	if condition != nil && condition.ObservedGeneration == nfLaunch.Generation {
+		return condition, nil
+	}
+	data, err := r.fetchParamsSchema(ctx, *nfLaunch)
+	if err != nil {
+		return nil, err
+	}
+	params, err := checkParams(nfLaunch.Spec.Params, data)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Incorrect pipeline schema")
+	}
+	setParamsStatus(nfLaunch, params, err)
+	return meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionParamsValid), nil
+}
+
+// Read the pipeline schema referenced by a launch
+func (r *NextflowLaunchReconciler) fetchParamsSchema(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) ([]byte, error) {
+	source := nfLaunch.Spec.ParamsSchema
+
+	if ref := source.ConfigMapKeyRef; ref != nil {
+		var configMap corev1.ConfigMap
+		err := r.Get(ctx, types.NamespacedName{Namespace: nfLaunch.Namespace, Name: ref.Name}, &configMap)
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := configMap.Data[ref.Key]; ok {
+			return []byte(value), nil
+		}
+		if value, ok := configMap.BinaryData[ref.Key]; ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("key %q not found in config map %s", ref.Key, ref.Name)
+	}
+
+	return fetchPublic(ctx, source.URL, maxParamsSchemaSize)
+}
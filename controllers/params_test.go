@@ -0,0 +1,92 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestValidateParamsSchema(t *testing.T) {
+	testRejected(t, specChanges{
+		"non-http schema URL": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ParamsSchema = &batchv1alpha1.NextflowLaunchParamsSchema{URL: "file:///schema.json"}
+		},
+		"schema with two sources": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ParamsSchema = &batchv1alpha1.NextflowLaunchParamsSchema{
+				URL:             "https://example.com/nextflow_schema.json",
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "schema.json"},
+			}
+		},
+		"config map reference without a name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ParamsSchema = &batchv1alpha1.NextflowLaunchParamsSchema{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "schema.json"},
+			}
+		},
+	})
+}
+
+func TestCheckParams(t *testing.T) {
+	schema := []byte(`{"properties": {
+		"input": {"type": "string"},
+		"genome": {"type": "string", "enum": ["GRCh38", "GRCm39"], "default": "GRCh38"}
+	}, "required": ["input"]}`)
+	status, err := checkParams(map[string]string{"genome": "hg19"}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`params.genome: "hg19" is not one of: GRCh38, GRCm39`,
+		`params.input: is required`,
+	}
+	if strings.Join(status.Errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected errors %q", status.Errors)
+	}
+
+	status, _ = checkParams(map[string]string{"input": "samples.csv"}, schema)
+	if len(status.Errors) > 0 || status.Effective["genome"] != "GRCh38" {
+		t.Errorf("unexpected result %+v", status)
+	}
+
+	nfLaunch := testLaunch()
+	nfLaunch.Generation = 2
+	setParamsStatus(&nfLaunch, status, nil)
+	condition := meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionParamsValid)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 2 {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+	status, _ = checkParams(map[string]string{"genome": "hg19"}, schema)
+	setParamsStatus(&nfLaunch, status, nil)
+	condition = meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionParamsValid)
+	if condition.Status != metav1.ConditionFalse || condition.Reason != "InvalidParams" ||
+		condition.Message != strings.Join(want, "; ") {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+	_, err = checkParams(nil, []byte("not json"))
+	setParamsStatus(&nfLaunch, nil, err)
+	condition = meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionParamsValid)
+	if err == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "InvalidSchema" ||
+		strings.Contains(condition.Message, err.Error()) {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+}
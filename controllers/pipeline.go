@@ -0,0 +1,103 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// directory of an inline pipeline script in the driver
+	scriptPath = "/tmp/nextflow-pipeline"
+	// key of an inline pipeline script in the config map
+	scriptFile = "main.nf"
+)
+
+// Check the pipeline section of a launch definition; a local path has to
+// be on the main volume or on one of the additional volumes
+func validatePipeline(spec batchv1alpha1.NextflowLaunchSpec) error {
+	pipeline := spec.Pipeline
+	sources := 0
+	for _, set := range []bool{pipeline.Source != "", pipeline.Script != "", pipeline.Path != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("spec.pipeline requires exactly one of source, script and path")
+	}
+	if pipeline.Source != "" {
+		return nil
+	}
+	if pipeline.Revision != "" {
+		return errors.New("spec.pipeline.revision requires a repository source")
+	}
+	if pipeline.Script != "" {
+		return nil
+	}
+
+	if !path.IsAbs(pipeline.Path) {
+		return errors.New("spec.pipeline.path must be an absolute path")
+	}
+	if !onVolume(spec, pipeline.Path) {
+		return errors.New("spec.pipeline.path must be on the storage volume or one of spec.volumes")
+	}
+	return nil
+}
+
+// Whether a path is on the storage volume or one of the additional volumes
+func onVolume(spec batchv1alpha1.NextflowLaunchSpec, p string) bool {
+	mountPaths := []string{spec.K8s["storageMountPath"]}
+	for _, volume := range spec.Volumes {
+		mountPaths = append(mountPaths, volume.MountPath)
+	}
+	for _, mountPath := range mountPaths {
+		if strings.HasPrefix(path.Clean(p)+"/", path.Clean(mountPath)+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// What Nextflow is told to run: the repository, the mounted inline script
+// or the local directory
+func pipelineTarget(pipeline batchv1alpha1.NextflowLaunchPipeline) string {
+	switch {
+	case pipeline.Script != "":
+		return scriptPath + "/" + scriptFile
+	case pipeline.Path != "":
+		return pipeline.Path
+	default:
+		return pipeline.Source
+	}
+}
+
+// Mount the inline pipeline script, kept in the config map, in the driver
+func mountScript(pod *corev1.Pod) {
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "nextflow-config",
+		MountPath: scriptPath + "/" + scriptFile,
+		SubPath:   scriptFile,
+		ReadOnly:  true,
+	})
+}
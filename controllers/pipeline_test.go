@@ -0,0 +1,68 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestPipelineScript(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline = batchv1alpha1.NextflowLaunchPipeline{Script: "workflow { println 'hi' }"}
+	})
+	command := nfLaunch.Spec.Nextflow.Command
+	if command[len(command)-1] != "/tmp/nextflow-pipeline/main.nf" {
+		t.Errorf("command does not run the inline script: %v", command)
+	}
+	configMap, _ := makeNextflowConfig(nfLaunch)
+	if configMap.Data["main.nf"] != nfLaunch.Spec.Pipeline.Script {
+		t.Errorf("script not stored in the config map: %v", configMap.Data)
+	}
+	pod, _ := makeNextflowPod(nfLaunch, configMap.Name)
+	mounted := false
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		mounted = mounted || (mount.MountPath == "/tmp/nextflow-pipeline/main.nf" && mount.SubPath == "main.nf")
+	}
+	if !mounted {
+		t.Errorf("script not mounted: %+v", pod.Spec.Containers[0].VolumeMounts)
+	}
+
+	nfLaunch = validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline = batchv1alpha1.NextflowLaunchPipeline{Path: defaultMountPath + "/pipelines/dev"}
+	})
+	command = nfLaunch.Spec.Nextflow.Command
+	if command[len(command)-1] != defaultMountPath+"/pipelines/dev" {
+		t.Errorf("command does not run the local pipeline: %v", command)
+	}
+
+	pipeline := func(pipeline batchv1alpha1.NextflowLaunchPipeline) func(*batchv1alpha1.NextflowLaunchSpec) {
+		return func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Pipeline = pipeline
+		}
+	}
+	testRejected(t, specChanges{
+		"pipeline without a source": pipeline(batchv1alpha1.NextflowLaunchPipeline{}),
+		"pipeline with two sources": pipeline(batchv1alpha1.NextflowLaunchPipeline{Source: "hello", Script: "workflow {}"}),
+		"pipeline outside volumes":  pipeline(batchv1alpha1.NextflowLaunchPipeline{Path: "/elsewhere/pipeline"}),
+		"local pipeline with a revision": pipeline(batchv1alpha1.NextflowLaunchPipeline{
+			Path:     defaultMountPath + "/pipeline",
+			Revision: "main",
+		}),
+	})
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+// name of the init container downloading the plugins
+const pluginsContainerName = "nextflow-plugins"
+
+// A plugin id, optionally pinned to a version
+var pluginPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*(@[0-9][0-9A-Za-z.+-]*)?$`)
+
+const (
+	// Nextflow's plugin registry, listing the releases of every plugin
+	// with the Nextflow versions they require
+	defaultPluginsIndexURL = "https://raw.githubusercontent.com/nextflow-io/plugins/main/plugins.json"
+	// Upper limit on the size of the plugin registry
+	maxPluginsIndexSize = 32 << 20
+	// condition reporting the outcome of the plugin check
+	conditionPluginsCompatible = "PluginsCompatible"
+)
+
+// A plugin of the registry
+type pluginIndexEntry struct {
+	ID       string `json:"id"`
+	Releases []struct {
+		Version  string `json:"version"`
+		Requires string `json:"requires"`
+	} `json:"releases"`
+}
+
+// Split a plugin into its id and version (empty if not pinned)
+func splitPlugin(plugin string) (string, string) {
+	id, version, _ := strings.Cut(plugin, "@")
+	return id, version
+}
+
+// Numeric parts of a version, e.g. [22 10 1] for 22.10.1-edge;
+// false if the version does not start with a number
+func versionNumbers(version string) ([]int, bool) {
+	version, _, _ = strings.Cut(version, "-")
+	var numbers []int
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, len(numbers) > 0
+}
+
+// Compare two versions by their numeric parts; false if either of them
+// cannot be parsed
+func compareVersions(a string, b string) (int, bool) {
+	x, okA := versionNumbers(a)
+	y, okB := versionNumbers(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var p, q int
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		if p != q {
+			if p < q {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// Parse the plugin registry into the Nextflow requirement of every release,
+// by plugin id and version
+func parsePluginsIndex(data []byte) (map[string]map[string]string, error) {
+	var entries []pluginIndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	index := map[string]map[string]string{}
+	for _, entry := range entries {
+		releases := map[string]string{}
+		for _, release := range entry.Releases {
+			releases[release.Version] = release.Requires
+		}
+		index[entry.ID] = releases
+	}
+	return index, nil
+}
+
+// Oldest Nextflow release satisfying a requirement of the form >=version
+func requiredNextflow(requires string) (string, bool) {
+	requires = strings.TrimSpace(requires)
+	if !strings.HasPrefix(requires, ">=") {
+		return "", false
+	}
+	version := strings.TrimSpace(strings.TrimPrefix(requires, ">="))
+	_, ok := versionNumbers(version)
+	return version, ok
+}
+
+// Check the pinned plugins of a launch definition against the Nextflow
+// version, using the requirements of the plugin registry. Pins that cannot
+// be checked (unknown releases, unsupported requirements) are rejected
+func checkPluginRequirements(spec batchv1alpha1.NextflowLaunchSpec, index map[string]map[string]string) error {
+	for i, plugin := range spec.Nextflow.Plugins {
+		id, version := splitPlugin(plugin)
+		if version == "" {
+			continue
+		}
+		requires, ok := index[id][version]
+		if !ok {
+			return fmt.Errorf("spec.nextflow.plugins[%d]: %s %s is not in the plugin registry", i, id, version)
+		}
+		nextflow, ok := requiredNextflow(requires)
+		if !ok {
+			return fmt.Errorf("spec.nextflow.plugins[%d]: requirement %q of %s %s cannot be checked", i, requires, id, version)
+		}
+		if cmp, _ := compareVersions(spec.Nextflow.Version, nextflow); cmp < 0 {
+			return fmt.Errorf("spec.nextflow.plugins[%d]: %s %s requires Nextflow %s or later, not %s",
+				i, id, version, nextflow, spec.Nextflow.Version)
+		}
+	}
+	return nil
+}
+
+// Check the plugins section of a launch definition
+func validatePlugins(spec batchv1alpha1.NextflowLaunchSpec) error {
+	seen := map[string]bool{}
+	for i, plugin := range spec.Nextflow.Plugins {
+		if !pluginPattern.MatchString(plugin) {
+			return fmt.Errorf("spec.nextflow.plugins[%d] %q is not of the form id@version", i, plugin)
+		}
+		id, version := splitPlugin(plugin)
+		if seen[id] {
+			return fmt.Errorf("spec.nextflow.plugins[%d] %q is duplicated", i, id)
+		}
+		seen[id] = true
+		if version == "" {
+			if spec.Offline != nil {
+				return fmt.Errorf("spec.nextflow.plugins[%d] %q requires a version in offline mode", i, id)
+			}
+			continue
+		}
+		// the requirements are checked against the registry or the
+		// offline plugins, which needs a release number
+		if _, ok := versionNumbers(spec.Nextflow.Version); !ok {
+			return fmt.Errorf("spec.nextflow.plugins[%d]: %s %s cannot be checked against Nextflow %q",
+				i, id, version, spec.Nextflow.Version)
+		}
+	}
+	if spec.Nextflow.PluginsDir != "" {
+		if spec.Offline != nil {
+			return errors.New("spec.nextflow.pluginsDir cannot be combined with spec.offline, which has its own plugins")
+		}
+		if !path.IsAbs(spec.Nextflow.PluginsDir) || !onVolume(spec, spec.Nextflow.PluginsDir) {
+			return errors.New("spec.nextflow.pluginsDir must be an absolute path on the storage volume or one of spec.volumes")
+		}
+	}
+	return nil
+}
+
+// Generate the "plugins" section of the config
+func configurePlugins(config *groovy.Block, plugins []string) {
+	if len(plugins) == 0 {
+		return
+	}
+	scope := config.Scope("plugins")
+	for _, plugin := range plugins {
+		scope.Call("id", groovy.String(plugin))
+	}
+}
+
+// Directory of a pinned plugin in the Nextflow plugins directory
+func pluginDir(plugin string) string {
+	id, version := splitPlugin(plugin)
+	return id + "-" + version
+}
+
+// Point the driver to the plugin cache and add the init container
+// downloading the plugins into it; the init container shares the
+// environment, the mounts and the security settings of the driver
+func addPluginsDownload(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	driver := &pod.Spec.Containers[0]
+	driver.Env = append(driver.Env, corev1.EnvVar{Name: "NXF_PLUGINS_DIR", Value: spec.Nextflow.PluginsDir})
+	if len(spec.Nextflow.Plugins) == 0 {
+		return
+	}
+
+	init := *driver.DeepCopy()
+	init.Name = pluginsContainerName
+	init.Command = []string{"nextflow", "plugin", "install", strings.Join(spec.Nextflow.Plugins, ",")}
+	init.Args = nil
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, init)
+}
+
+// Whether the plugins of a launch are checked against the registry; offline
+// plugins are checked in the driver pod, against their manifests
+func hasRegistryPins(spec batchv1alpha1.NextflowLaunchSpec) bool {
+	if spec.Offline != nil {
+		return false
+	}
+	for _, plugin := range spec.Nextflow.Plugins {
+		if _, version := splitPlugin(plugin); version != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Check the pinned plugins of a launch against the registry, unless they
+// have already been checked for the current generation; returns the
+// PluginsCompatible condition
+func (r *NextflowLaunchReconciler) checkLaunchPlugins(ctx context.Context, nfLaunch *batchv1alpha1.NextflowLaunch) (*metav1.Condition, error) {
+	condition := meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionPluginsCompatible)
+	if condition != nil && condition.ObservedGeneration == nfLaunch.Generation {
+		return condition, nil
+	}
+	url := r.PluginsIndexURL
+	if url == "" {
+		url = defaultPluginsIndexURL
+	}
+	data, err := fetchURL(ctx, operatorClient, url, maxPluginsIndexSize)
+	if err != nil {
+		return nil, err
+	}
+	index, err := parsePluginsIndex(data)
+	if err != nil {
+		return nil, err
+	}
+	checked := metav1.Condition{
+		Type:               conditionPluginsCompatible,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: nfLaunch.Generation,
+		Reason:             "Compatible",
+		Message:            "Pinned plugins support Nextflow " + nfLaunch.Spec.Nextflow.Version,
+	}
+	if err := checkPluginRequirements(nfLaunch.Spec, index); err != nil {
+		checked.Status = metav1.ConditionFalse
+		checked.Reason = "Incompatible"
+		checked.Message = err.Error()
+	}
+	meta.SetStatusCondition(&nfLaunch.Status.Conditions, checked)
+	return meta.FindStatusCondition(nfLaunch.Status.Conditions, conditionPluginsCompatible), nil
+}
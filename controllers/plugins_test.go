@@ -0,0 +1,179 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+// Excerpt of the plugin registry
+const testPluginsIndex = `[
+	{"id": "nf-amazon", "releases": [
+		{"version": "1.16.2", "requires": ">=22.10.0"},
+		{"version": "2.1.0", "requires": ">=23.05.0-edge"}
+	]},
+	{"id": "nf-validation", "releases": [
+		{"version": "1.1.3", "requires": ">=23.04.0"},
+		{"version": "0.9.0", "requires": "22.10.0"}
+	]}
+]`
+
+func TestPluginRequirements(t *testing.T) {
+	index, err := parsePluginsIndex([]byte(testPluginsIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		version string
+		plugins []string
+		err     string
+	}{
+		{"23.04.1", []string{"nf-validation@1.1.3", "nf-amazon", "nf-amazon@1.16.2"}, ""},
+		{"23.10.0", []string{"nf-amazon@2.1.0"}, ""},
+		{"23.05.0-edge", []string{"nf-amazon@2.1.0"}, ""},
+		{"23.04.1", []string{"nf-amazon@2.1.0"}, "requires Nextflow 23.05.0-edge or later"},
+		{"22.10.1", []string{"nf-validation@1.1.3"}, "requires Nextflow 23.04.0 or later"},
+		{"23.04.1", []string{"nf-amazon@9.9.9"}, "is not in the plugin registry"},
+		{"23.04.1", []string{"nf-unknown@1.0.0"}, "is not in the plugin registry"},
+		{"23.04.1", []string{"nf-validation@0.9.0"}, "cannot be checked"},
+	} {
+		spec := testLaunch().Spec
+		spec.Nextflow.Version = c.version
+		spec.Nextflow.Plugins = c.plugins
+		err := checkPluginRequirements(spec, index)
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("plugins %v rejected with Nextflow %s: %v", c.plugins, c.version, err)
+		case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+			t.Errorf("plugins %v with Nextflow %s: got error %v, want %q", c.plugins, c.version, err, c.err)
+		}
+	}
+
+	spec := testLaunch().Spec
+	spec.Nextflow.Plugins = []string{"nf-amazon@2.1.0"}
+	if !hasRegistryPins(spec) {
+		t.Error("pinned plugin not checked against the registry")
+	}
+	spec.Offline = &batchv1alpha1.NextflowLaunchOffline{}
+	if hasRegistryPins(spec) {
+		t.Error("offline plugin checked against the registry")
+	}
+}
+
+func TestOfflinePluginRequirements(t *testing.T) {
+	if _, err := exec.LookPath("awk"); err != nil {
+		t.Skip("awk is not available")
+	}
+	dir := t.TempDir()
+	for plugin, manifest := range map[string]string{
+		"nf-amazon-2.1.0":     "Manifest-Version: 1.0\nPlugin-Requires: >=23.05.0-edge\n",
+		"nf-validation-1.1.3": "Manifest-Version: 1.0\n",
+	} {
+		path := filepath.Join(dir, plugin, "classes", "META-INF")
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "MANIFEST.MF"), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "framework"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		version string
+		plugin  string
+		err     string
+	}{
+		{"23.10.0", "nf-amazon@2.1.0", ""},
+		{"23.04.1", "nf-amazon@2.1.0", "Plugin nf-amazon@2.1.0 requires Nextflow 23.05.0 or later, not 23.04.1"},
+		{"23.10.0", "nf-validation@1.1.3", "Nextflow requirement of plugin nf-validation@1.1.3 not found in its manifest"},
+	} {
+		spec := testLaunch().Spec
+		spec.Offline = &batchv1alpha1.NextflowLaunchOffline{Home: dir}
+		spec.Pipeline.Source = ""
+		spec.Nextflow.Version = c.version
+		spec.Nextflow.Plugins = []string{c.plugin}
+		if err := os.MkdirAll(filepath.Join(dir, "framework", c.version), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		// the termination log is not writable here; the reason is also
+		// written to stderr
+		script := strings.Replace(offlineScript(spec), "> /dev/termination-log", "> /dev/null", 1)
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "NXF_HOME=" + dir, "NXF_PLUGINS_DIR=" + dir}
+		out, err := cmd.CombinedOutput()
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s rejected with Nextflow %s: %s", c.plugin, c.version, out)
+		case c.err != "" && strings.TrimSpace(string(out)) != c.err:
+			t.Errorf("%s with Nextflow %s: got %q, want %q", c.plugin, c.version, out, c.err)
+		}
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Nextflow.Version = "23.04.1"
+		spec.Nextflow.Plugins = []string{"nf-validation@1.1.3", "nf-amazon"}
+		spec.Nextflow.PluginsDir = defaultMountPath + "/.nextflow-plugins"
+	})
+	testConfigContains(t, testConfig(t, nfLaunch), "plugins {\n    id 'nf-validation@1.1.3'\n    id 'nf-amazon'\n}\n")
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	if len(pod.Spec.InitContainers) != 1 ||
+		strings.Join(pod.Spec.InitContainers[0].Command, " ") != "nextflow plugin install nf-validation@1.1.3,nf-amazon" {
+		t.Fatalf("unexpected init containers %+v", pod.Spec.InitContainers)
+	}
+
+	for version, want := range map[string]int{"22.10.0": -1, "23.04.0-edge": 0, "23.10.1": 1, "23.04": 0} {
+		if got, ok := compareVersions(version, "23.04.0"); !ok || got != want {
+			t.Errorf("%s compared to 23.04.0 is %d, want %d", version, got, want)
+		}
+	}
+
+	nfLaunch = validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Offline = &batchv1alpha1.NextflowLaunchOffline{}
+		spec.Nextflow.Version = "23.04.1"
+		spec.Nextflow.Plugins = []string{"nf-prov@1.0.0"}
+	})
+	if script := offlineScript(nfLaunch.Spec); !strings.Contains(script, `"$NXF_PLUGINS_DIR"/'nf-prov-1.0.0'`) {
+		t.Errorf("plugins not checked:\n%s", script)
+	}
+
+	plugins := func(version string, plugins ...string) func(*batchv1alpha1.NextflowLaunchSpec) {
+		return func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Version = version
+			spec.Nextflow.Plugins = plugins
+		}
+	}
+	testRejected(t, specChanges{
+		"duplicated plugin":                plugins("22.10.1", "nf-prov", "nf-prov@1.0.0"),
+		"empty plugin version":             plugins("22.10.1", "nf-prov@"),
+		"pinned plugin with Nextflow edge": plugins("edge", "nf-prov@1.0.0"),
+		"plugins directory outside volumes": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Nextflow.Plugins = []string{"nf-prov@1.0.0"}
+			spec.Nextflow.PluginsDir = "/opt/plugins"
+		},
+	})
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+// Parse a map of strings as a single (legacy) pod option.
+// If one of the values equals to "(map)", the remaining items are nested
+// under that key: "key: [key1: 'value1', key2: 'value2', ...]".
+// Otherwise the items make a flat map
+func podOption(x map[string]string) groovy.Map {
+	key := ""
+	values := map[string]groovy.Value{}
+	for k, v := range x {
+		if v == "(map)" {
+			key = k
+		} else {
+			values[k] = groovy.String(v)
+		}
+	}
+	if key != "" {
+		return groovy.Map{{Key: key, Value: groovy.SortedMap(values)}}
+	}
+	return groovy.SortedMap(values)
+}
+
+// Parse a list of (legacy) pod options
+func podOptions(x []map[string]string) groovy.List {
+	options := groovy.List{}
+	for _, option := range x {
+		options = append(options, podOption(option))
+	}
+	return options
+}
+
+// Convert a Kubernetes object (e.g. affinity) to a config value
+// through its JSON representation
+func objectValue(x interface{}) (groovy.Value, error) {
+	data, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	var object interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return groovy.ValueOf(object)
+}
+
+// Reference to a Secret or ConfigMap, optionally narrowed to a single key
+func mountTarget(mount batchv1alpha1.NextflowLaunchPodMount) string {
+	if mount.Key == "" {
+		return mount.Name
+	}
+	return mount.Name + "/" + mount.Key
+}
+
+// Render typed pod options as a list of Nextflow pod directive entries
+func typedPodOptions(opts *batchv1alpha1.NextflowLaunchPodOptions) (groovy.List, error) {
+	options := groovy.List{}
+	if opts == nil {
+		return options, nil
+	}
+
+	for _, env := range opts.Env {
+		option := groovy.Map{{Key: "env", Value: groovy.String(env.Name)}}
+		switch {
+		case env.SecretKeyRef != nil:
+			option = append(option, groovy.MapEntry{Key: "secret",
+				Value: groovy.String(env.SecretKeyRef.Name + "/" + env.SecretKeyRef.Key)})
+		case env.ConfigMapKeyRef != nil:
+			option = append(option, groovy.MapEntry{Key: "config",
+				Value: groovy.String(env.ConfigMapKeyRef.Name + "/" + env.ConfigMapKeyRef.Key)})
+		case env.FieldPath != "":
+			option = append(option, groovy.MapEntry{Key: "fieldPath", Value: groovy.String(env.FieldPath)})
+		default:
+			option = append(option, groovy.MapEntry{Key: "value", Value: groovy.String(env.Value)})
+		}
+		options = append(options, option)
+	}
+	for _, secret := range opts.Secrets {
+		options = append(options, groovy.Map{
+			{Key: "secret", Value: groovy.String(mountTarget(secret))},
+			{Key: "mountPath", Value: groovy.String(secret.MountPath)},
+		})
+	}
+	for _, config := range opts.ConfigMaps {
+		options = append(options, groovy.Map{
+			{Key: "config", Value: groovy.String(mountTarget(config))},
+			{Key: "mountPath", Value: groovy.String(config.MountPath)},
+		})
+	}
+	for _, claim := range opts.VolumeClaims {
+		option := groovy.Map{
+			{Key: "volumeClaim", Value: groovy.String(claim.ClaimName)},
+			{Key: "mountPath", Value: groovy.String(claim.MountPath)},
+		}
+		if claim.SubPath != "" {
+			option = append(option, groovy.MapEntry{Key: "subPath", Value: groovy.String(claim.SubPath)})
+		}
+		if claim.ReadOnly {
+			option = append(option, groovy.MapEntry{Key: "readOnly", Value: groovy.Bool(true)})
+		}
+		options = append(options, option)
+	}
+	for _, key := range sortedKeys(opts.Labels) {
+		options = append(options, groovy.Map{
+			{Key: "label", Value: groovy.String(key)},
+			{Key: "value", Value: groovy.String(opts.Labels[key])},
+		})
+	}
+	for _, key := range sortedKeys(opts.Annotations) {
+		options = append(options, groovy.Map{
+			{Key: "annotation", Value: groovy.String(key)},
+			{Key: "value", Value: groovy.String(opts.Annotations[key])},
+		})
+	}
+	if len(opts.NodeSelector) > 0 {
+		options = append(options, groovy.Map{
+			{Key: "nodeSelector", Value: groovy.SortedMap(groovyStrings(opts.NodeSelector))},
+		})
+	}
+	for _, toleration := range opts.Tolerations {
+		value, err := objectValue(toleration)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, groovy.Map{{Key: "toleration", Value: value}})
+	}
+	if opts.Affinity != nil {
+		value, err := objectValue(opts.Affinity)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, groovy.Map{{Key: "affinity", Value: value}})
+	}
+	if opts.SecurityContext != nil {
+		value, err := objectValue(opts.SecurityContext)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, groovy.Map{{Key: "securityContext", Value: value}})
+	}
+	for _, option := range []struct{ key, value string }{
+		{"imagePullSecret", opts.ImagePullSecret},
+		{"priorityClassName", opts.PriorityClassName},
+		{"runtimeClassName", opts.RuntimeClassName},
+	} {
+		if option.value != "" {
+			options = append(options, groovy.Map{{Key: option.key, Value: groovy.String(option.value)}})
+		}
+	}
+	return options, nil
+}
+
+// Combine the legacy and the typed pod options into a single pod directive
+func workerPodOptions(legacy []map[string]string, typed *batchv1alpha1.NextflowLaunchPodOptions) (groovy.List, error) {
+	options, err := typedPodOptions(typed)
+	if err != nil {
+		return nil, err
+	}
+	return append(podOptions(legacy), options...), nil
+}
+
+// Check typed pod options; path is the location of the options in the spec
+func validatePodOptions(path string, opts *batchv1alpha1.NextflowLaunchPodOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for i, env := range opts.Env {
+		if env.Name == "" {
+			return fmt.Errorf("%s.env[%d].name is required", path, i)
+		}
+		sources := 0
+		for _, set := range []bool{env.Value != "", env.SecretKeyRef != nil, env.ConfigMapKeyRef != nil, env.FieldPath != ""} {
+			if set {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return fmt.Errorf("%s.env[%d] accepts only one of value, secretKeyRef, configMapKeyRef and fieldPath", path, i)
+		}
+		if env.SecretKeyRef != nil && (env.SecretKeyRef.Name == "" || env.SecretKeyRef.Key == "") {
+			return fmt.Errorf("%s.env[%d].secretKeyRef requires name and key", path, i)
+		}
+		if env.ConfigMapKeyRef != nil && (env.ConfigMapKeyRef.Name == "" || env.ConfigMapKeyRef.Key == "") {
+			return fmt.Errorf("%s.env[%d].configMapKeyRef requires name and key", path, i)
+		}
+	}
+	for _, field := range []struct {
+		name   string
+		mounts []batchv1alpha1.NextflowLaunchPodMount
+	}{
+		{"secrets", opts.Secrets},
+		{"configMaps", opts.ConfigMaps},
+	} {
+		for i, mount := range field.mounts {
+			if mount.Name == "" || mount.MountPath == "" {
+				return fmt.Errorf("%s.%s[%d] requires name and mountPath", path, field.name, i)
+			}
+		}
+	}
+	for i, claim := range opts.VolumeClaims {
+		if claim.ClaimName == "" || claim.MountPath == "" {
+			return fmt.Errorf("%s.volumeClaims[%d] requires claimName and mountPath", path, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestMakeNextflowConfigPodOptions(t *testing.T) {
+	seconds := int64(60)
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pod = []map[string]string{{"label": "legacy", "value": "yes"}}
+		spec.PodOptions = &batchv1alpha1.NextflowLaunchPodOptions{
+			Env: []batchv1alpha1.NextflowLaunchPodEnv{
+				{Name: "MODE", Value: "fast"},
+				{Name: "TOKEN", SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+					Key:                  "token",
+				}},
+			},
+			VolumeClaims: []batchv1alpha1.NextflowLaunchPodVolumeClaim{
+				{ClaimName: "refs", MountPath: "/refs", ReadOnly: true},
+			},
+			NodeSelector: map[string]string{"kubernetes.io/arch": "amd64"},
+			Tolerations: []corev1.Toleration{
+				{Key: "spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: "gpu", Operator: corev1.TolerationOpExists, TolerationSeconds: &seconds},
+			},
+			PriorityClassName: "batch",
+		}
+	})
+	testConfigContains(t, testConfig(t, nfLaunch), `pod = [`+
+		`[label: 'legacy', value: 'yes'], `+
+		`[env: 'MODE', value: 'fast'], `+
+		`[env: 'TOKEN', secret: 'db/token'], `+
+		`[volumeClaim: 'refs', mountPath: '/refs', readOnly: true], `+
+		`[nodeSelector: ['kubernetes.io/arch': 'amd64']], `+
+		`[toleration: [effect: 'NoSchedule', key: 'spot', operator: 'Exists']], `+
+		`[toleration: [key: 'gpu', operator: 'Exists', tolerationSeconds: 60]], `+
+		`[priorityClassName: 'batch']]`)
+
+	testRejected(t, specChanges{
+		"env with two sources": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.PodOptions = &batchv1alpha1.NextflowLaunchPodOptions{
+				Env: []batchv1alpha1.NextflowLaunchPodEnv{{Name: "MODE", Value: "fast", FieldPath: "metadata.name"}},
+			}
+		},
+		"process env with two sources": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Processes = []batchv1alpha1.NextflowLaunchProcess{{
+				WithName: "ALIGN",
+				PodOptions: &batchv1alpha1.NextflowLaunchPodOptions{
+					Env: []batchv1alpha1.NextflowLaunchPodEnv{{Name: "MODE", Value: "fast", FieldPath: "metadata.name"}},
+				},
+			}}
+		},
+	})
+}
+
+// Global pod options (secrets, volumes) are repeated in the pod options of
+// process selectors and profiles, which replace rather than extend them
+func TestPodOptionsKeepGlobal(t *testing.T) {
+	refs := []batchv1alpha1.NextflowLaunchVolume{{
+		MountPath:             "/refs",
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "refs"},
+	}}
+	gpu := &batchv1alpha1.NextflowLaunchPodOptions{NodeSelector: map[string]string{"gpu": "true"}}
+	for _, c := range []struct {
+		name   string
+		change func(spec *batchv1alpha1.NextflowLaunchSpec)
+		// the blocks of the config, each of which must contain all of want;
+		// the last one must also contain its own options
+		blocks []string
+		want   []string
+		last   []string
+	}{
+		{
+			name: "process",
+			change: func(spec *batchv1alpha1.NextflowLaunchSpec) {
+				spec.Secrets = []batchv1alpha1.NextflowLaunchSecret{{
+					Name: "API_KEY",
+					SecretKeyRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "api"},
+						Key:                  "key",
+					},
+				}}
+				spec.Volumes = refs
+				spec.Processes = []batchv1alpha1.NextflowLaunchProcess{{WithName: "ALIGN", PodOptions: gpu}}
+			},
+			blocks: []string{"withName: 'ALIGN'"},
+			want: []string{
+				`[secret: 'api/key', mountPath: '/var/run/secrets/nextflow/API_KEY']`,
+				`[volumeClaim: 'refs', mountPath: '/refs']`,
+				`[nodeSelector: [gpu: 'true']]`,
+			},
+		},
+		{
+			name: "profile",
+			change: func(spec *batchv1alpha1.NextflowLaunchSpec) {
+				spec.Volumes = refs
+				spec.ProfileDefinitions = []batchv1alpha1.NextflowLaunchProfile{{
+					Name:       "gpu",
+					PodOptions: gpu,
+					Processes: []batchv1alpha1.NextflowLaunchProcess{{
+						WithName: "ALIGN",
+						PodOptions: &batchv1alpha1.NextflowLaunchPodOptions{
+							Labels: map[string]string{"stage": "align"},
+						},
+					}},
+				}}
+			},
+			blocks: []string{"profiles {", "withName: 'ALIGN'"},
+			want: []string{
+				`[volumeClaim: 'refs', mountPath: '/refs']`,
+				`[nodeSelector: [gpu: 'true']]`,
+			},
+			last: []string{`[label: 'stage', value: 'align']`},
+		},
+	} {
+		config := testConfig(t, validTestLaunch(t, c.change))
+		// split the config at the start of each block
+		var parts []string
+		rest := config
+		for _, block := range c.blocks {
+			start := strings.Index(rest, block)
+			if start < 0 {
+				t.Fatalf("%s: no %q in the config:\n%s", c.name, block, config)
+			}
+			parts = append(parts, rest[:start])
+			rest = rest[start:]
+		}
+		parts = append(parts[1:], rest)
+		for i, part := range parts {
+			want := c.want
+			if i == len(parts)-1 {
+				want = append(want, c.last...)
+			}
+			for _, want := range want {
+				if !strings.Contains(part, want) {
+					t.Errorf("%s: %s pod options do not contain %q:\n%s", c.name, c.blocks[i], want, config)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// name of the init container pulling the pipeline
+	prefetchContainerName = "nextflow-pull"
+	// condition reporting the outcome of the pull
+	conditionPipelineFetched = "PipelineFetched"
+)
+
+// Quote a string for the shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Local directory of a pipeline in the Nextflow assets, following the
+// Nextflow naming rules: "name" stands for "nextflow-io/name", repository
+// URLs are reduced to "owner/repository"
+func projectName(source string) (string, error) {
+	if strings.Contains(source, "://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return "", err
+		}
+		source = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	} else if strings.HasPrefix(source, "git@") {
+		// scp-like syntax: git@host:owner/repository.git
+		if i := strings.Index(source, ":"); i >= 0 {
+			source = strings.TrimSuffix(source[i+1:], ".git")
+		}
+	}
+	parts := strings.Split(source, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return "nextflow-io/" + parts[0], nil
+	case len(parts) >= 2 && parts[len(parts)-2] != "" && parts[len(parts)-1] != "":
+		return parts[len(parts)-2] + "/" + parts[len(parts)-1], nil
+	default:
+		return "", fmt.Errorf("cannot determine the project name of %q", source)
+	}
+}
+
+// Check the prefetch settings of a launch definition
+func validatePrefetch(pipeline batchv1alpha1.NextflowLaunchPipeline) error {
+	if !pipeline.Prefetch {
+		return nil
+	}
+	if pipeline.Source == "" || strings.HasPrefix(pipeline.Source, "/") || strings.HasPrefix(pipeline.Source, ".") {
+		return errors.New("spec.pipeline.prefetch requires a repository source, not a local path")
+	}
+	if _, err := projectName(pipeline.Source); err != nil {
+		return fmt.Errorf("spec.pipeline.source: %w", err)
+	}
+	if pipeline.AssetsDir != "" && !path.IsAbs(pipeline.AssetsDir) {
+		return errors.New("spec.pipeline.assetsDir must be an absolute path")
+	}
+	return nil
+}
+
+// Shell script pulling the pipeline and writing the checked out commit to
+// the termination message. Git is not needed: the commit is read from the
+// HEAD file, the ref it points to, or the packed refs
+func prefetchScript(pipeline batchv1alpha1.NextflowLaunchPipeline) string {
+	project, _ := projectName(pipeline.Source)
+	pull := "nextflow pull " + shellQuote(pipeline.Source)
+	if pipeline.Revision != "" {
+		pull += " -r " + shellQuote(pipeline.Revision)
+	}
+	return strings.Join([]string{
+		"set -e",
+		pull,
+		`git="$NXF_ASSETS"/` + shellQuote(project) + "/.git",
+		`head=$(cat "$git/HEAD")`,
+		`case "$head" in`,
+		`ref:*) ref="${head#ref: }"`,
+		`  if [ -f "$git/$ref" ]; then sha=$(cat "$git/$ref"); else sha=$(grep " $ref\$" "$git/packed-refs" | cut -d" " -f1); fi ;;`,
+		`*) sha="$head" ;;`,
+		`esac`,
+		`echo "$sha" > /dev/termination-log`,
+	}, "\n")
+}
+
+// Add the init container pulling the pipeline to the driver pod; it shares
+// the environment, the mounts and the security settings of the driver
+func addPrefetch(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	assets := corev1.EnvVar{Name: "NXF_ASSETS", Value: spec.Pipeline.AssetsDir}
+	driver := &pod.Spec.Containers[0]
+	driver.Env = append(driver.Env, assets)
+
+	init := *driver.DeepCopy()
+	init.Name = prefetchContainerName
+	init.Command = []string{"sh", "-c", prefetchScript(spec.Pipeline)}
+	init.Args = nil
+	init.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, init)
+}
+
+// Record the outcome of the pull in the status of a launch;
+// returns true if the status has changed
+func updatePrefetchStatus(status *batchv1alpha1.NextflowLaunchStatus, pod corev1.Pod) bool {
+	for _, container := range pod.Status.InitContainerStatuses {
+		terminated := container.State.Terminated
+		if container.Name != prefetchContainerName || terminated == nil {
+			continue
+		}
+		condition := metav1.Condition{
+			Type:    conditionPipelineFetched,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Pulled",
+			Message: "Pipeline pulled",
+		}
+		if terminated.ExitCode == 0 {
+			status.CommitID = strings.TrimSpace(terminated.Message)
+			condition.Message = "Pipeline pulled at commit " + status.CommitID
+		} else {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "CloneFailed"
+			condition.Message = strings.TrimSpace(terminated.Message)
+		}
+		existing := meta.FindStatusCondition(status.Conditions, conditionPipelineFetched)
+		if existing != nil && existing.Status == condition.Status && existing.Message == condition.Message {
+			return false
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestPrefetch(t *testing.T) {
+	for source, want := range map[string]string{
+		"hello":                                "nextflow-io/hello",
+		"nf-core/rnaseq":                       "nf-core/rnaseq",
+		"https://github.com/nf-core/sarek.git": "nf-core/sarek",
+		"git@gitlab.com:group/pipeline.git":    "group/pipeline",
+	} {
+		if got, err := projectName(source); err != nil || got != want {
+			t.Errorf("project name of %q is %q (%v), want %q", source, got, err, want)
+		}
+	}
+
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Pipeline.Prefetch = true
+		spec.Pipeline.Revision = "v1.1"
+	})
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	if len(pod.Spec.InitContainers) != 1 {
+		t.Fatalf("unexpected init containers %+v", pod.Spec.InitContainers)
+	}
+	script := pod.Spec.InitContainers[0].Command[2]
+	if !strings.Contains(script, "nextflow pull 'hello' -r 'v1.1'") ||
+		!strings.Contains(script, `"$NXF_ASSETS"/'nextflow-io/hello'/.git`) {
+		t.Errorf("unexpected script:\n%s", script)
+	}
+	assets := defaultMountPath + "/.nextflow-assets"
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers[0]) {
+		if containerEnv(container)["NXF_ASSETS"] != assets {
+			t.Errorf("container %s does not use the assets in %s", container.Name, assets)
+		}
+	}
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name: prefetchContainerName,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 0,
+			Message:  "0123abcd\n",
+		}},
+	}}
+	var status batchv1alpha1.NextflowLaunchStatus
+	if !updatePrefetchStatus(&status, pod) || status.CommitID != "0123abcd" {
+		t.Errorf("commit not recorded: %+v", status)
+	}
+	if updatePrefetchStatus(&status, pod) {
+		t.Error("unchanged status reported as changed")
+	}
+	pod.Status.InitContainerStatuses[0].State.Terminated = &corev1.ContainerStateTerminated{
+		ExitCode: 1,
+		Message:  "Cannot find revision `v1.1`",
+	}
+	updatePrefetchStatus(&status, pod)
+	if status.Conditions[0].Reason != "CloneFailed" || status.Conditions[0].Status != metav1.ConditionFalse {
+		t.Errorf("clone failure not reported: %+v", status.Conditions)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+// Name of the service account created for a launch
+func serviceAccountName(nfLaunch batchv1alpha1.NextflowLaunch) string {
+	return nfLaunch.Name + "-nextflow"
+}
+
+// Check the serviceAccount section of a launch definition
+func validateServiceAccount(spec batchv1alpha1.NextflowLaunchSpec) error {
+	account := spec.ServiceAccount
+	if account == nil {
+		return nil
+	}
+	if !account.Create && account.Name == "" {
+		return fmt.Errorf("spec.serviceAccount requires name unless create is set")
+	}
+	if account.Name != "" {
+		if errs := validation.IsDNS1123Subdomain(account.Name); len(errs) > 0 {
+			return fmt.Errorf("spec.serviceAccount.name %q is invalid: %s", account.Name, errs[0])
+		}
+	}
+	return nil
+}
+
+// Permissions needed by the Nextflow k8s executor in the driver
+func driverRules(spec batchv1alpha1.NextflowLaunchSpec) []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list", "watch", "create", "delete"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/status", "pods/log"},
+			Verbs:     []string{"get"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"persistentvolumeclaims"},
+			Verbs:     []string{"get", "list"},
+		},
+	}
+	if spec.K8s["computeResourceType"] == "Job" {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"get", "list", "watch", "create", "delete"},
+		})
+	}
+	return rules
+}
+
+// Construct the service account, the role and the role binding of a launch
+func makeServiceAccount(nfLaunch batchv1alpha1.NextflowLaunch) (corev1.ServiceAccount, rbacv1.Role, rbacv1.RoleBinding) {
+	meta := metav1.ObjectMeta{
+		Name:      nfLaunch.Spec.ServiceAccount.Name,
+		Namespace: nfLaunch.Namespace,
+	}
+	account := corev1.ServiceAccount{ObjectMeta: meta}
+	role := rbacv1.Role{
+		ObjectMeta: meta,
+		Rules:      driverRules(nfLaunch.Spec),
+	}
+	binding := rbacv1.RoleBinding{
+		ObjectMeta: meta,
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     meta.Name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      meta.Name,
+			Namespace: meta.Namespace,
+		}},
+	}
+	return account, role, binding
+}
+
+// Returned when an object the launch would create already exists and
+// belongs to someone else
+var errNotOwned = errors.New("exists and is not owned by the launch")
+
+// Check that an object is either new or controlled by the launch, so that
+// an existing service account or role is never taken over
+func checkOwner(nfLaunch batchv1alpha1.NextflowLaunch, kind string, object metav1.Object) error {
+	if object.GetResourceVersion() != "" && !metav1.IsControlledBy(object, &nfLaunch) {
+		return fmt.Errorf("%s %s %w", kind, object.GetName(), errNotOwned)
+	}
+	return nil
+}
+
+// Whether an error comes from an object the launch does not own
+func isNotOwned(err error) bool {
+	return errors.Is(err, errNotOwned)
+}
+
+// Create (or update) the service account of a launch and its permissions
+func (r *NextflowLaunchReconciler) createServiceAccount(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) error {
+	account, role, binding := makeServiceAccount(nfLaunch)
+
+	current := corev1.ServiceAccount{ObjectMeta: account.ObjectMeta}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, &current, func() error {
+		if err := checkOwner(nfLaunch, "service account", &current); err != nil {
+			return err
+		}
+		return ctrl.SetControllerReference(&nfLaunch, &current, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	currentRole := rbacv1.Role{ObjectMeta: role.ObjectMeta}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &currentRole, func() error {
+		if err := checkOwner(nfLaunch, "role", &currentRole); err != nil {
+			return err
+		}
+		currentRole.Rules = role.Rules
+		return ctrl.SetControllerReference(&nfLaunch, &currentRole, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	currentBinding := rbacv1.RoleBinding{ObjectMeta: binding.ObjectMeta}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, &currentBinding, func() error {
+		if err := checkOwner(nfLaunch, "role binding", &currentBinding); err != nil {
+			return err
+		}
+		currentBinding.RoleRef = binding.RoleRef
+		currentBinding.Subjects = binding.Subjects
+		return ctrl.SetControllerReference(&nfLaunch, &currentBinding, r.Scheme)
+	})
+	return err
+}
+
+// List the driver permissions the service account of a launch lacks,
+// as "<verb> <resource>" entries
+func (r *NextflowLaunchReconciler) missingPermissions(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) ([]string, error) {
+	user := "system:serviceaccount:" + nfLaunch.Namespace + ":" + nfLaunch.Spec.ServiceAccount.Name
+	var missing []string
+	for _, rule := range driverRules(nfLaunch.Spec) {
+		for _, resource := range rule.Resources {
+			name, subresource, _ := strings.Cut(resource, "/")
+			for _, verb := range rule.Verbs {
+				review := authorizationv1.SubjectAccessReview{
+					Spec: authorizationv1.SubjectAccessReviewSpec{
+						User:   user,
+						Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + nfLaunch.Namespace},
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Namespace:   nfLaunch.Namespace,
+							Verb:        verb,
+							Group:       rule.APIGroups[0],
+							Resource:    name,
+							Subresource: subresource,
+						},
+					},
+				}
+				err := r.Create(ctx, &review)
+				if err != nil {
+					return nil, err
+				}
+				if !review.Status.Allowed {
+					missing = append(missing, verb+" "+resource)
+				}
+			}
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestServiceAccount(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.ServiceAccount = &batchv1alpha1.NextflowLaunchServiceAccount{Create: true}
+		spec.K8s["computeResourceType"] = "Job"
+	})
+	if name := nfLaunch.Spec.ServiceAccount.Name; name != "test-launch-nextflow" || nfLaunch.Spec.K8s["serviceAccount"] != name {
+		t.Errorf("unexpected service account %q (k8s: %q)", name, nfLaunch.Spec.K8s["serviceAccount"])
+	}
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	if pod.Spec.ServiceAccountName != "test-launch-nextflow" {
+		t.Errorf("driver runs as %q", pod.Spec.ServiceAccountName)
+	}
+
+	account, role, binding := makeServiceAccount(nfLaunch)
+	if account.Name != role.Name || binding.RoleRef.Name != role.Name || binding.Subjects[0].Name != account.Name {
+		t.Errorf("objects do not match: %s, %s, %+v", account.Name, role.Name, binding)
+	}
+	jobs := false
+	for _, rule := range role.Rules {
+		jobs = jobs || rule.APIGroups[0] == "batch"
+	}
+	if !jobs {
+		t.Errorf("role does not allow jobs: %+v", role.Rules)
+	}
+
+	nfLaunch.UID = "launch-uid"
+	if err := checkOwner(nfLaunch, "role", &role); err != nil {
+		t.Errorf("new role rejected: %v", err)
+	}
+	role.ResourceVersion = "1"
+	if err := checkOwner(nfLaunch, "role", &role); !isNotOwned(err) {
+		t.Errorf("existing role without owner accepted: %v", err)
+	}
+	controller := true
+	role.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "batch.mnm.bio/v1alpha1",
+		Kind:       "NextflowLaunch",
+		Name:       nfLaunch.Name,
+		UID:        nfLaunch.UID,
+		Controller: &controller,
+	}}
+	if err := checkOwner(nfLaunch, "role", &role); err != nil {
+		t.Errorf("role owned by the launch rejected: %v", err)
+	}
+
+	testRejected(t, specChanges{
+		"conflicting service accounts": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ServiceAccount = &batchv1alpha1.NextflowLaunchServiceAccount{Name: "pipelines"}
+			spec.K8s["serviceAccount"] = "other"
+		},
+	})
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+// Check the per-process configuration of a launch definition
+func validateProcesses(path string, processes []batchv1alpha1.NextflowLaunchProcess) error {
+	for i, process := range processes {
+		if (process.WithName == "") == (process.WithLabel == "") {
+			return fmt.Errorf("%s[%d] requires exactly one of withName and withLabel", path, i)
+		}
+		switch process.ErrorStrategy {
+		case "", "terminate", "finish", "ignore", "retry":
+		default:
+			return fmt.Errorf("%s[%d].errorStrategy %q is not supported", path, i, process.ErrorStrategy)
+		}
+		if process.Cpus != nil && *process.Cpus < 1 {
+			return fmt.Errorf("%s[%d].cpus must be positive", path, i)
+		}
+		if process.MaxRetries != nil && *process.MaxRetries < 0 {
+			return fmt.Errorf("%s[%d].maxRetries cannot be negative", path, i)
+		}
+		if err := validatePodOptions(fmt.Sprintf("%s[%d].podOptions", path, i), process.PodOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add a selector block for each process definition to the process scope.
+// Nextflow does not merge the pod directive of a selector with the one of
+// the scope, so the per-process pod options are appended to the base ones
+func configureProcesses(scope *groovy.Block, processes []batchv1alpha1.NextflowLaunchProcess, base groovy.List) error {
+	for _, process := range processes {
+		var block *groovy.Block
+		if process.WithName != "" {
+			block = scope.Selector("withName", process.WithName)
+		} else {
+			block = scope.Selector("withLabel", process.WithLabel)
+		}
+
+		if process.Cpus != nil {
+			block.Set("cpus", groovy.Int(*process.Cpus))
+		}
+		if process.Memory != "" {
+			block.Set("memory", groovy.String(process.Memory))
+		}
+		if process.Time != "" {
+			block.Set("time", groovy.String(process.Time))
+		}
+		if process.Disk != "" {
+			block.Set("disk", groovy.String(process.Disk))
+		}
+		if process.Container != "" {
+			block.Set("container", groovy.String(process.Container))
+		}
+		if process.ErrorStrategy != "" {
+			block.Set("errorStrategy", groovy.String(process.ErrorStrategy))
+		}
+		if process.MaxRetries != nil {
+			block.Set("maxRetries", groovy.Int(*process.MaxRetries))
+		}
+		if process.Queue != "" {
+			block.Set("queue", groovy.String(process.Queue))
+		}
+		for _, key := range sortedKeys(process.Ext) {
+			block.Set("ext."+key, groovy.String(process.Ext[key]))
+		}
+		pod, err := workerPodOptions(process.Pod, process.PodOptions)
+		if err != nil {
+			return err
+		}
+		if len(pod) > 0 {
+			block.Set("pod", append(append(groovy.List{}, base...), pod...))
+		}
+	}
+	return nil
+}
+
+// A container engine section together with the name of its config scope
+type containerEngine struct {
+	name   string
+	engine *batchv1alpha1.NextflowLaunchContainerEngine
+}
+
+// Container engines, in the order their scopes are written to the config
+func containerEngines(spec batchv1alpha1.NextflowLaunchSpec) []containerEngine {
+	return []containerEngine{
+		{"docker", spec.Docker},
+		{"singularity", spec.Singularity},
+		{"apptainer", spec.Apptainer},
+		{"podman", spec.Podman},
+		{"charliecloud", spec.Charliecloud},
+	}
+}
+
+// Check the container engine, Wave and Fusion sections of a launch definition
+func validateContainers(spec batchv1alpha1.NextflowLaunchSpec) error {
+	enabled := ""
+	for _, e := range containerEngines(spec) {
+		if e.engine == nil {
+			continue
+		}
+		if e.engine.Enabled {
+			if enabled != "" {
+				return fmt.Errorf("spec.%s and spec.%s cannot be enabled at the same time", enabled, e.name)
+			}
+			enabled = e.name
+		}
+		if e.engine.CacheDir != "" && (e.name == "docker" || e.name == "podman") {
+			return fmt.Errorf("spec.%s.cacheDir is not supported", e.name)
+		}
+		if e.engine.AutoMounts != nil && e.name != "singularity" && e.name != "apptainer" {
+			return fmt.Errorf("spec.%s.autoMounts is not supported", e.name)
+		}
+	}
+	if spec.Wave != nil {
+		for _, strategy := range spec.Wave.Strategy {
+			switch strategy {
+			case "conda", "spack", "container", "dockerfile":
+			default:
+				return fmt.Errorf("spec.wave.strategy %q is not supported", strategy)
+			}
+		}
+	}
+	if spec.Fusion != nil && spec.Fusion.Enabled {
+		if spec.Wave == nil || !spec.Wave.Enabled {
+			return errors.New("spec.fusion requires spec.wave to be enabled")
+		}
+		if enabled == "charliecloud" {
+			return errors.New("spec.fusion cannot be used with charliecloud")
+		}
+	}
+	return nil
+}
+
+// Add the container engine, Wave and Fusion scopes to the config
+func configureContainers(config *groovy.Block, spec batchv1alpha1.NextflowLaunchSpec) {
+	for _, e := range containerEngines(spec) {
+		if e.engine == nil {
+			continue
+		}
+		scope := config.Scope(e.name)
+		scope.Set("enabled", groovy.Bool(e.engine.Enabled))
+		if e.engine.Registry != "" {
+			scope.Set("registry", groovy.String(e.engine.Registry))
+		}
+		if e.engine.RunOptions != "" {
+			scope.Set("runOptions", groovy.String(e.engine.RunOptions))
+		}
+		if e.engine.CacheDir != "" {
+			scope.Set("cacheDir", groovy.String(e.engine.CacheDir))
+		}
+		if e.engine.AutoMounts != nil {
+			scope.Set("autoMounts", groovy.Bool(*e.engine.AutoMounts))
+		}
+	}
+
+	if wave := spec.Wave; wave != nil {
+		scope := config.Scope("wave")
+		scope.Set("enabled", groovy.Bool(wave.Enabled))
+		if wave.Endpoint != "" {
+			scope.Set("endpoint", groovy.String(wave.Endpoint))
+		}
+		if len(wave.Strategy) > 0 {
+			strategy := groovy.List{}
+			for _, s := range wave.Strategy {
+				strategy = append(strategy, groovy.String(s))
+			}
+			scope.Set("strategy", strategy)
+		}
+		if wave.Freeze {
+			scope.Set("freeze", groovy.Bool(true))
+		}
+		if wave.BuildRepository != "" {
+			scope.Set("build.repository", groovy.String(wave.BuildRepository))
+		}
+		if wave.CacheRepository != "" {
+			scope.Set("build.cacheRepository", groovy.String(wave.CacheRepository))
+		}
+	}
+
+	if fusion := spec.Fusion; fusion != nil {
+		scope := config.Scope("fusion")
+		scope.Set("enabled", groovy.Bool(fusion.Enabled))
+		if fusion.ExportStorageCredentials {
+			scope.Set("exportStorageCredentials", groovy.Bool(true))
+		}
+		if fusion.ContainerConfigURL != "" {
+			scope.Set("containerConfigUrl", groovy.String(fusion.ContainerConfigURL))
+		}
+	}
+}
+
+// An execution report section together with the name of its config scope
+type executionReport struct {
+	name   string
+	file   string
+	report *batchv1alpha1.NextflowLaunchReport
+}
+
+// Execution reports, in the order their scopes are written to the config,
+// along with their default file names
+func executionReports(spec batchv1alpha1.NextflowLaunchSpec) []executionReport {
+	if spec.Reports == nil {
+		return nil
+	}
+	return []executionReport{
+		{"trace", "trace.txt", spec.Reports.Trace},
+		{"report", "report.html", spec.Reports.Report},
+		{"timeline", "timeline.html", spec.Reports.Timeline},
+		{"dag", "dag.html", spec.Reports.Dag},
+	}
+}
+
+// Set the default paths of the enabled reports (under launchDir)
+func defaultReportFiles(spec batchv1alpha1.NextflowLaunchSpec) {
+	for _, r := range executionReports(spec) {
+		if r.report != nil && r.report.Enabled && r.report.File == "" {
+			r.report.File = spec.K8s["launchDir"] + "/" + reportsDir + "/" + r.file
+		}
+	}
+}
+
+// Add the scopes of the enabled reports to the config
+func configureReports(config *groovy.Block, spec batchv1alpha1.NextflowLaunchSpec) {
+	for _, r := range executionReports(spec) {
+		if r.report == nil || !r.report.Enabled {
+			continue
+		}
+		scope := config.Scope(r.name)
+		scope.Set("enabled", groovy.Bool(true))
+		scope.Set("file", groovy.String(r.report.File))
+		scope.Set("overwrite", groovy.Bool(true))
+	}
+}
+
+// Paths of the files produced by the enabled reports
+func reportArtifacts(spec batchv1alpha1.NextflowLaunchSpec) map[string]string {
+	artifacts := map[string]string{}
+	for _, r := range executionReports(spec) {
+		if r.report != nil && r.report.Enabled {
+			artifacts[r.name] = r.report.File
+		}
+	}
+	if len(artifacts) == 0 {
+		return nil
+	}
+	return artifacts
+}
+
+// Allowed profile names; a comma would split the -profile argument
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// k8s settings the driver relies on, which a profile must not change; the
+// service account of the workers is bound to the permissions of the launch
+var fixedK8sSettings = []string{"storageClaimName", "storageMountPath", "launchDir", "workDir", "serviceAccount"}
+
+// Selected profiles, in order: the legacy profile setting, then the list
+func profileList(spec batchv1alpha1.NextflowLaunchSpec) []string {
+	var profiles []string
+	seen := map[string]bool{}
+	for _, name := range append(strings.Split(spec.Profile, ","), spec.Profiles...) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
+// Check the profiles and the profile definitions of a launch definition
+func validateProfiles(spec batchv1alpha1.NextflowLaunchSpec) error {
+	for i, name := range spec.Profiles {
+		if !profileNamePattern.MatchString(name) {
+			return fmt.Errorf("spec.profiles[%d] %q is not a valid profile name", i, name)
+		}
+	}
+	seen := map[string]bool{}
+	for i, profile := range spec.ProfileDefinitions {
+		path := fmt.Sprintf("spec.profileDefinitions[%d]", i)
+		if !profileNamePattern.MatchString(profile.Name) {
+			return fmt.Errorf("%s.name %q is not a valid profile name", path, profile.Name)
+		}
+		if seen[profile.Name] {
+			return fmt.Errorf("%s.name %q is duplicated", path, profile.Name)
+		}
+		seen[profile.Name] = true
+		for _, key := range fixedK8sSettings {
+			if _, ok := profile.K8s[key]; ok {
+				return fmt.Errorf("%s.k8s.%s cannot be set in a profile", path, key)
+			}
+		}
+		if err := validateProcesses(path+".processes", profile.Processes); err != nil {
+			return err
+		}
+		if err := validatePodOptions(path+".podOptions", profile.PodOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add the inline profile definitions to the profiles scope; as for the
+// process selectors, the pod options of a profile are appended to the base ones
+func configureProfiles(config *groovy.Block, profiles []batchv1alpha1.NextflowLaunchProfile, base groovy.List) error {
+	for _, profile := range profiles {
+		block := config.Scope("profiles").Scope(profile.Name)
+		if len(profile.Params) > 0 {
+			block.Scope("params").SetAll(groovyValues(profile.Params))
+		}
+		if len(profile.Env) > 0 {
+			block.Scope("env").SetAll(groovyStrings(profile.Env))
+		}
+		if len(profile.K8s) > 0 {
+			block.Scope("k8s").SetAll(groovyValues(profile.K8s))
+		}
+		if profile.PodOptions != nil || len(profile.Processes) > 0 {
+			process := block.Scope("process")
+			pod, err := typedPodOptions(profile.PodOptions)
+			if err != nil {
+				return err
+			}
+			if len(pod) > 0 {
+				pod = append(append(groovy.List{}, base...), pod...)
+				process.Set("pod", pod)
+			} else {
+				pod = base
+			}
+			if err := configureProcesses(process, profile.Processes, pod); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
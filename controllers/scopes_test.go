@@ -0,0 +1,164 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestConfigureScopes(t *testing.T) {
+	cpus := int32(16)
+	for _, c := range []struct {
+		name   string
+		change func(spec *batchv1alpha1.NextflowLaunchSpec)
+		want   string
+	}{
+		{
+			name: "process selectors",
+			change: func(spec *batchv1alpha1.NextflowLaunchSpec) {
+				spec.Processes = []batchv1alpha1.NextflowLaunchProcess{
+					{
+						WithName: "ALIGN|SORT",
+						Cpus:     &cpus,
+						Memory:   "64 GB",
+						Ext:      map[string]string{"args": "--very-sensitive"},
+					},
+					{
+						WithLabel:     "small",
+						ErrorStrategy: "retry",
+					},
+				}
+			},
+			want: `    withName: 'ALIGN|SORT' {
+        cpus = 16
+        memory = '64 GB'
+        ext.args = '--very-sensitive'
+    }
+    withLabel: 'small' {
+        errorStrategy = 'retry'
+    }
+`,
+		},
+		{
+			name: "wave strategy",
+			change: func(spec *batchv1alpha1.NextflowLaunchSpec) {
+				spec.Wave = &batchv1alpha1.NextflowLaunchWave{Enabled: true, Strategy: []string{"conda", "container"}}
+				spec.Fusion = &batchv1alpha1.NextflowLaunchFusion{Enabled: true}
+			},
+			want: "strategy = ['conda', 'container']",
+		},
+		{
+			name: "profile definition",
+			change: func(spec *batchv1alpha1.NextflowLaunchSpec) {
+				spec.Profiles = []string{"site"}
+				spec.ProfileDefinitions = []batchv1alpha1.NextflowLaunchProfile{{
+					Name:      "site",
+					Params:    map[string]string{"max_cpus": "32"},
+					K8s:       map[string]string{"computeResourceType": "Job"},
+					Processes: []batchv1alpha1.NextflowLaunchProcess{{WithLabel: "small", Cpus: &cpus}},
+				}}
+			},
+			want: `profiles {
+    site {
+        params {
+            max_cpus = '32'
+        }
+        k8s {
+            computeResourceType = 'Job'
+        }
+        process {
+            withLabel: 'small' {
+                cpus = 16
+            }
+        }
+    }
+}
+`,
+		},
+	} {
+		config := testConfig(t, validTestLaunch(t, c.change))
+		if !strings.Contains(config, c.want) {
+			t.Errorf("%s: config does not contain %q:\n%s", c.name, c.want, config)
+		}
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	testRejected(t, specChanges{
+		"process with two selectors": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Processes = []batchv1alpha1.NextflowLaunchProcess{{WithName: "ALIGN", WithLabel: "small"}}
+		},
+		"two container engines": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Docker = &batchv1alpha1.NextflowLaunchContainerEngine{Enabled: true}
+			spec.Singularity = &batchv1alpha1.NextflowLaunchContainerEngine{Enabled: true}
+		},
+		"fusion without wave": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Fusion = &batchv1alpha1.NextflowLaunchFusion{Enabled: true}
+		},
+		"invalid profile name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Profiles = []string{"test,docker"}
+		},
+		"duplicated profile definition": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ProfileDefinitions = []batchv1alpha1.NextflowLaunchProfile{{Name: "site"}, {Name: "site"}}
+		},
+		"profile overriding workDir": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ProfileDefinitions = []batchv1alpha1.NextflowLaunchProfile{{
+				Name: "site",
+				K8s:  map[string]string{"workDir": "/elsewhere"},
+			}}
+		},
+		"profile overriding serviceAccount": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.ProfileDefinitions = []batchv1alpha1.NextflowLaunchProfile{{
+				Name: "site",
+				K8s:  map[string]string{"serviceAccount": "other"},
+			}}
+		},
+	})
+}
+
+func TestProfiles(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Profile = "docker"
+		spec.Profiles = []string{"test", "site", "docker"}
+	})
+	command := strings.Join(nfLaunch.Spec.Nextflow.Command, " ")
+	if !strings.Contains(command, "-profile docker,test,site ") {
+		t.Errorf("unexpected command %q", command)
+	}
+}
+
+func TestReports(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Reports = &batchv1alpha1.NextflowLaunchReports{
+			Trace: &batchv1alpha1.NextflowLaunchReport{Enabled: true},
+			Dag:   &batchv1alpha1.NextflowLaunchReport{Enabled: true, File: "/workspace/dag.mmd"},
+		}
+	})
+	artifacts := reportArtifacts(nfLaunch.Spec)
+	if artifacts["trace"] != "/workspace/test-launch/reports/trace.txt" {
+		t.Errorf("unexpected trace path %q", artifacts["trace"])
+	}
+	if artifacts["dag"] != "/workspace/dag.mmd" {
+		t.Errorf("unexpected dag path %q", artifacts["dag"])
+	}
+	if _, ok := artifacts["report"]; ok {
+		t.Error("disabled report listed as an artifact")
+	}
+}
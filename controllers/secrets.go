@@ -0,0 +1,192 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// name of the init container installing the secrets store
+	secretsContainerName = "nextflow-secrets"
+	// where the init container mounts the secret holding the store
+	secretsSourcePath = "/tmp/nextflow-secrets-source"
+)
+
+// Nextflow only accepts identifier-like secret names
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A single entry of Nextflow's local secrets store
+type secretsStoreEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Name of the Kubernetes secret holding the launch's Nextflow secrets store
+func secretsStoreName(nfLaunch batchv1alpha1.NextflowLaunch) string {
+	return nfLaunch.Name + "-nextflow-secrets"
+}
+
+// Path at which a Nextflow secret is mounted in the worker pods
+func secretMountPath(secret batchv1alpha1.NextflowLaunchSecret) string {
+	if secret.MountPath != "" {
+		return secret.MountPath
+	}
+	return defaultSecretMountPath + "/" + secret.Name
+}
+
+// Pod options mounting the referenced Kubernetes secrets in the workers.
+// Only secret names and keys end up in the config, never the values
+func secretPodMounts(secrets []batchv1alpha1.NextflowLaunchSecret) []batchv1alpha1.NextflowLaunchPodMount {
+	var mounts []batchv1alpha1.NextflowLaunchPodMount
+	for _, secret := range secrets {
+		mounts = append(mounts, batchv1alpha1.NextflowLaunchPodMount{
+			Name:      secret.SecretKeyRef.Name,
+			Key:       secret.SecretKeyRef.Key,
+			MountPath: secretMountPath(secret),
+		})
+	}
+	return mounts
+}
+
+// Check the secrets section of a launch definition
+func validateSecrets(secrets []batchv1alpha1.NextflowLaunchSecret) error {
+	seen := map[string]bool{}
+	for i, secret := range secrets {
+		if !secretNamePattern.MatchString(secret.Name) {
+			return fmt.Errorf("spec.secrets[%d].name %q is not a valid Nextflow secret name", i, secret.Name)
+		}
+		if seen[secret.Name] {
+			return fmt.Errorf("spec.secrets[%d].name %q is duplicated", i, secret.Name)
+		}
+		seen[secret.Name] = true
+		if secret.SecretKeyRef.Name == "" || secret.SecretKeyRef.Key == "" {
+			return fmt.Errorf("spec.secrets[%d].secretKeyRef requires both name and key", i)
+		}
+	}
+	return nil
+}
+
+// Construct a Nextflow local secrets store as a Kubernetes secret.
+// The values are looked up by the name of the Nextflow secret
+func makeSecretsStore(nfLaunch batchv1alpha1.NextflowLaunch, values map[string]string) (corev1.Secret, error) {
+
+	entries := []secretsStoreEntry{}
+	for _, secret := range nfLaunch.Spec.Secrets {
+		entries = append(entries, secretsStoreEntry{
+			Name:  secret.Name,
+			Value: values[secret.Name],
+		})
+	}
+	store, err := json.Marshal(entries)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretsStoreName(nfLaunch),
+			Namespace: nfLaunch.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			secretsStoreFile: store,
+		},
+	}, nil
+}
+
+// Retrieve the values of the Kubernetes secrets referenced by the launch
+func (r *NextflowLaunchReconciler) fetchSecretValues(ctx context.Context, nfLaunch batchv1alpha1.NextflowLaunch) (map[string]string, error) {
+
+	values := map[string]string{}
+	for _, secret := range nfLaunch.Spec.Secrets {
+		var source corev1.Secret
+		name := types.NamespacedName{
+			Namespace: nfLaunch.Namespace,
+			Name:      secret.SecretKeyRef.Name,
+		}
+		err := r.Get(ctx, name, &source)
+		if err != nil {
+			if secret.SecretKeyRef.Optional != nil && *secret.SecretKeyRef.Optional {
+				continue
+			}
+			return nil, err
+		}
+		value, ok := source.Data[secret.SecretKeyRef.Key]
+		if !ok {
+			if secret.SecretKeyRef.Optional != nil && *secret.SecretKeyRef.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("key %q not found in secret %q", secret.SecretKeyRef.Key, secret.SecretKeyRef.Name)
+		}
+		values[secret.Name] = string(value)
+	}
+	return values, nil
+}
+
+// Attach the Nextflow secrets store to the driver. Nextflow only reads a
+// store that is private to its user, while secret files are owned by root,
+// so an init container copies it into a memory-backed volume, as the driver
+// user and with mode 0600
+func mountSecretsStore(pod *corev1.Pod, nfLaunch batchv1alpha1.NextflowLaunch) {
+	driver := &pod.Spec.Containers[0]
+	// readable by the fsGroup, for a non-root init container
+	sourceMode := int32(0440)
+	store := secretsPath + "/" + secretsStoreFile
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name: "nextflow-secrets-source",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  secretsStoreName(nfLaunch),
+					DefaultMode: &sourceMode,
+				},
+			},
+		},
+		corev1.Volume{
+			Name:         "nextflow-secrets",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}},
+		},
+	)
+	storeMount := corev1.VolumeMount{Name: "nextflow-secrets", MountPath: secretsPath}
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:  secretsContainerName,
+		Image: driver.Image,
+		Command: []string{"sh", "-c", "cp " + secretsSourcePath + "/" + secretsStoreFile + " " + store +
+			" && chmod 600 " + store},
+		Resources:       driver.Resources,
+		SecurityContext: driver.SecurityContext.DeepCopy(),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "nextflow-secrets-source", MountPath: secretsSourcePath, ReadOnly: true},
+			storeMount,
+		},
+	})
+
+	storeMount.ReadOnly = true
+	driver.VolumeMounts = append(driver.VolumeMounts, storeMount)
+	driver.Env = append(driver.Env, corev1.EnvVar{Name: "NXF_SECRETS_FILE", Value: store})
+}
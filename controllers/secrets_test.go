@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestSecrets(t *testing.T) {
+	secrets := []batchv1alpha1.NextflowLaunchSecret{
+		{
+			Name: "API_KEY",
+			SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "api"},
+				Key:                  "key",
+			},
+		},
+		{
+			Name: "DB_PASSWORD",
+			SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+				Key:                  "password",
+			},
+			MountPath: "/secrets/db",
+		},
+	}
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Secrets = secrets
+	})
+
+	store, err := makeSecretsStore(nfLaunch, map[string]string{"API_KEY": "k3y", "DB_PASSWORD": "it's"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"name":"API_KEY","value":"k3y"},{"name":"DB_PASSWORD","value":"it's"}]`
+	if store.Name != "test-launch-nextflow-secrets" || string(store.Data[secretsStoreFile]) != want {
+		t.Errorf("unexpected secrets store %s: %s", store.Name, store.Data[secretsStoreFile])
+	}
+
+	// the store is copied as the driver user, also when it is not root
+	for _, security := range []*batchv1alpha1.NextflowLaunchSecurity{nil, {Restricted: true}} {
+		nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Secrets = secrets
+			spec.Security = security
+		})
+		pod, _ := makeNextflowPod(nfLaunch, "config")
+		volumes := map[string]corev1.Volume{}
+		for _, volume := range pod.Spec.Volumes {
+			volumes[volume.Name] = volume
+		}
+		source := volumes["nextflow-secrets-source"].Secret
+		if source == nil || source.SecretName != store.Name || *source.DefaultMode != 0440 ||
+			volumes["nextflow-secrets"].EmptyDir == nil {
+			t.Errorf("unexpected secrets store volumes %+v", volumes)
+		}
+		var init *corev1.Container
+		for i := range pod.Spec.InitContainers {
+			if pod.Spec.InitContainers[i].Name == secretsContainerName {
+				init = &pod.Spec.InitContainers[i]
+			}
+		}
+		if init == nil || !strings.Contains(init.Command[2], "chmod 600 "+secretsPath+"/"+secretsStoreFile) ||
+			(security != nil && (init.SecurityContext == nil || *init.SecurityContext.AllowPrivilegeEscalation)) {
+			t.Errorf("unexpected init container %+v", init)
+		}
+		if security != nil && *pod.Spec.SecurityContext.RunAsUser != defaultRunAsUser {
+			t.Errorf("unexpected pod security context %+v", pod.Spec.SecurityContext)
+		}
+		if env := containerEnv(pod.Spec.Containers[0]); env["NXF_SECRETS_FILE"] != secretsPath+"/"+secretsStoreFile {
+			t.Errorf("unexpected driver environment %v", env)
+		}
+	}
+
+	config := testConfig(t, nfLaunch)
+	testConfigContains(t, config,
+		`[secret: 'api/key', mountPath: '/var/run/secrets/nextflow/API_KEY']`,
+		`[secret: 'db/password', mountPath: '/secrets/db']`,
+	)
+	if strings.Contains(config, "k3y") {
+		t.Errorf("config contains a secret value:\n%s", config)
+	}
+
+	testRejected(t, specChanges{
+		"duplicated secret name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Secrets = append(secrets[:1:1], secrets[0])
+		},
+		"invalid secret name": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Secrets = []batchv1alpha1.NextflowLaunchSecret{{Name: "api-key", SecretKeyRef: secrets[0].SecretKeyRef}}
+		},
+	})
+}
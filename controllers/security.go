@@ -0,0 +1,153 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+const (
+	// Non-root user and group the pods run as under the restricted standard,
+	// unless set otherwise
+	defaultRunAsUser = int64(1000)
+	// name of the init container seeding the Nextflow home
+	homeContainerName = "nextflow-home"
+	// where the init container mounts the writable Nextflow home
+	homeSeedPath = "/tmp/nextflow-home"
+)
+
+// Fill in the settings implied by the restricted Pod Security Standard
+// and check that the explicit ones do not contradict it
+func defaultSecurity(security *batchv1alpha1.NextflowLaunchSecurity) (*batchv1alpha1.NextflowLaunchSecurity, error) {
+	if security == nil || !security.Restricted {
+		return security, nil
+	}
+	security = security.DeepCopy()
+
+	if security.RunAsNonRoot == nil {
+		nonRoot := true
+		security.RunAsNonRoot = &nonRoot
+	}
+	for _, id := range []**int64{&security.RunAsUser, &security.RunAsGroup, &security.FSGroup} {
+		if *id == nil {
+			value := defaultRunAsUser
+			*id = &value
+		}
+	}
+	if security.SeccompProfile == nil {
+		security.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	if len(security.DropCapabilities) == 0 {
+		security.DropCapabilities = []corev1.Capability{"ALL"}
+	}
+
+	if !*security.RunAsNonRoot || *security.RunAsUser == 0 {
+		return nil, errors.New("spec.security.restricted does not allow running as root")
+	}
+	if security.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		return nil, errors.New("spec.security.restricted does not allow an unconfined seccomp profile")
+	}
+	all := false
+	for _, capability := range security.DropCapabilities {
+		all = all || strings.EqualFold(string(capability), "ALL")
+	}
+	if !all {
+		return nil, errors.New("spec.security.restricted requires dropping ALL capabilities")
+	}
+	return security, nil
+}
+
+// Pod-level security context, shared by the driver and the workers
+func podSecurityContext(security *batchv1alpha1.NextflowLaunchSecurity) *corev1.PodSecurityContext {
+	context := corev1.PodSecurityContext{
+		RunAsNonRoot:   security.RunAsNonRoot,
+		RunAsUser:      security.RunAsUser,
+		RunAsGroup:     security.RunAsGroup,
+		FSGroup:        security.FSGroup,
+		SeccompProfile: security.SeccompProfile,
+	}
+	return context.DeepCopy()
+}
+
+// Apply the security settings to the driver pod. A read-only root
+// filesystem requires writable volumes for Nextflow home and /tmp; the
+// home volume is seeded with the content of the image
+func secureDriver(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	security := spec.Security
+	if security == nil {
+		return
+	}
+	pod.Spec.SecurityContext = podSecurityContext(security)
+
+	container := &pod.Spec.Containers[0]
+	context := &corev1.SecurityContext{}
+	if len(security.DropCapabilities) > 0 {
+		context.Capabilities = &corev1.Capabilities{Drop: security.DropCapabilities}
+	}
+	if security.Restricted {
+		escalation := false
+		context.AllowPrivilegeEscalation = &escalation
+	}
+	if security.ReadOnlyRootFilesystem {
+		readOnly := true
+		context.ReadOnlyRootFilesystem = &readOnly
+	}
+	container.SecurityContext = context
+
+	// with a non-root user (or a read-only image) the defaults are not writable
+	if !security.Restricted && !security.ReadOnlyRootFilesystem {
+		return
+	}
+	scratch := []struct{ name, mountPath string }{{"nextflow-tmp", "/tmp"}}
+	if !onVolume(spec, spec.Nextflow.Home) {
+		scratch = append(scratch, struct{ name, mountPath string }{"nextflow-home", spec.Nextflow.Home})
+		addHomeSeed(pod, spec)
+	}
+	for _, dir := range scratch {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         dir.name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      dir.name,
+			MountPath: dir.mountPath,
+		})
+	}
+}
+
+// Add the init container copying the Nextflow home of the image (with the
+// preinstalled runtime) into the writable volume mounted over it
+func addHomeSeed(pod *corev1.Pod, spec batchv1alpha1.NextflowLaunchSpec) {
+	driver := pod.Spec.Containers[0]
+	home := shellQuote(spec.Nextflow.Home)
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:            homeContainerName,
+		Image:           driver.Image,
+		Command:         []string{"sh", "-c", "[ ! -d " + home + " ] || cp -R " + home + "/. " + homeSeedPath},
+		Resources:       driver.Resources,
+		SecurityContext: driver.SecurityContext.DeepCopy(),
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      "nextflow-home",
+			MountPath: homeSeedPath,
+		}},
+	})
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestSecurity(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Security = &batchv1alpha1.NextflowLaunchSecurity{
+			Restricted:             true,
+			ReadOnlyRootFilesystem: true,
+		}
+	})
+	pod, err := makeNextflowPod(nfLaunch, "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	context := pod.Spec.SecurityContext
+	if context == nil || !*context.RunAsNonRoot || *context.RunAsUser != defaultRunAsUser ||
+		context.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Errorf("unexpected pod security context %+v", context)
+	}
+	container := pod.Spec.Containers[0].SecurityContext
+	if container == nil || *container.AllowPrivilegeEscalation || !*container.ReadOnlyRootFilesystem ||
+		container.Capabilities.Drop[0] != "ALL" {
+		t.Errorf("unexpected container security context %+v", container)
+	}
+	writable := map[string]bool{}
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		writable[mount.MountPath] = !mount.ReadOnly
+	}
+	if !writable["/tmp"] || !writable[defaultNextflowHome] {
+		t.Errorf("no writable scratch space: %+v", pod.Spec.Containers[0].VolumeMounts)
+	}
+	seed := pod.Spec.InitContainers[0]
+	if seed.Name != homeContainerName || seed.VolumeMounts[0].Name != "nextflow-home" ||
+		seed.VolumeMounts[0].MountPath == defaultNextflowHome || *seed.SecurityContext.AllowPrivilegeEscalation {
+		t.Errorf("unexpected init container %+v", seed)
+	}
+	if script := seed.Command[2]; !strings.Contains(script, "cp -R '"+defaultNextflowHome+"'/. "+homeSeedPath) {
+		t.Errorf("unexpected script %q", script)
+	}
+
+	testConfigContains(t, testConfig(t, nfLaunch),
+		`[securityContext: [fsGroup: 1000, runAsGroup: 1000, runAsNonRoot: true, runAsUser: 1000, seccompProfile: [type: 'RuntimeDefault']]]`)
+
+	root := int64(0)
+	testRejected(t, specChanges{
+		"restricted launch running as root": func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Security = &batchv1alpha1.NextflowLaunchSecurity{Restricted: true, RunAsUser: &root}
+		},
+	})
+}
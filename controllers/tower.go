@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/tower"
+)
+
+// Environment variable holding the run name in the driver
+const towerRunNameEnv = "TOWER_RUN_NAME"
+
+// Nextflow run names are limited to lowercase letters, digits, - and _;
+// they start with a letter, and - and _ are followed by a letter or a digit
+var (
+	invalidRunNameChars  = regexp.MustCompile(`[^a-z0-9_-]+`)
+	repeatedRunNameSeps  = regexp.MustCompile(`([-_])[-_]+`)
+	invalidRunNamePrefix = regexp.MustCompile(`^[^a-z]+`)
+)
+
+// Check the Seqera Platform section of a launch definition
+func validateTower(spec batchv1alpha1.NextflowLaunchSpec) error {
+	if spec.Tower == nil {
+		return nil
+	}
+	ref := spec.Tower.AccessTokenSecretRef
+	if ref.Name == "" || ref.Key == "" {
+		return errors.New("spec.tower.accessTokenSecretRef requires both name and key")
+	}
+	return nil
+}
+
+// Add the tower scope to the config; the access token is passed
+// to the driver in an environment variable instead
+func configureTower(config *groovy.Block, spec batchv1alpha1.NextflowLaunchSpec) {
+	if spec.Tower == nil {
+		return
+	}
+	scope := config.Scope("tower")
+	scope.Set("enabled", groovy.Bool(true))
+	scope.Set("endpoint", groovy.String(towerEndpoint(spec.Tower)))
+	if spec.Tower.WorkspaceID != "" {
+		scope.Set("workspaceId", groovy.String(spec.Tower.WorkspaceID))
+	}
+}
+
+// Seqera Platform API endpoint of the launch
+func towerEndpoint(t *batchv1alpha1.NextflowLaunchTower) string {
+	if t.Endpoint != "" {
+		return t.Endpoint
+	}
+	return tower.DefaultEndpoint
+}
+
+// Generate a unique Nextflow run name for a launch, so that the run
+// can be found in Seqera Platform
+func towerRunName(nfLaunch batchv1alpha1.NextflowLaunch) string {
+	name := invalidRunNameChars.ReplaceAllString(strings.ToLower(nfLaunch.Name), "-")
+	name = repeatedRunNameSeps.ReplaceAllString(name, "$1")
+	name = invalidRunNamePrefix.ReplaceAllString(name, "")
+	if len(name) > 71 {
+		name = name[:71]
+	}
+	name = strings.TrimRight(name, "-_")
+	if name == "" {
+		name = "run"
+	}
+	return name + "-" + generateHash(8)
+}
+
+// Look up the launch's run in Seqera Platform and record its URL
+func (r *NextflowLaunchReconciler) updateTowerStatus(ctx context.Context, nfLaunch *batchv1alpha1.NextflowLaunch) error {
+
+	status := nfLaunch.Status.Tower
+	if nfLaunch.Spec.Tower == nil || status == nil || status.RunName == "" || status.RunURL != "" {
+		return nil
+	}
+
+	ref := nfLaunch.Spec.Tower.AccessTokenSecretRef
+	var secret corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Namespace: nfLaunch.Namespace, Name: ref.Name}, &secret)
+	if err != nil {
+		return err
+	}
+	token, ok := secret.Data[ref.Key]
+	if !ok {
+		return fmt.Errorf("key %q not found in secret %q", ref.Key, ref.Name)
+	}
+
+	client := tower.NewClient(towerEndpoint(nfLaunch.Spec.Tower), strings.TrimSpace(string(token)))
+	run, err := client.FindRun(ctx, nfLaunch.Spec.Tower.WorkspaceID, status.RunName)
+	if err != nil {
+		return err
+	}
+	status.WorkflowID = run.ID
+	status.RunURL = run.URL
+	return nil
+}
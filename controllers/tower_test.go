@@ -0,0 +1,72 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestTowerRunName(t *testing.T) {
+	// Nextflow's pattern, without the lookahead
+	pattern := regexp.MustCompile(`^[a-z]([a-z0-9]|[-_][a-z0-9])*$`)
+	for _, name := range []string{
+		"test-launch",
+		"1-rnaseq",
+		"my.launch--2",
+		"a_-b",
+		"---",
+		strings.Repeat("x", 70) + "-" + strings.Repeat("y", 20),
+	} {
+		nfLaunch := testLaunch()
+		nfLaunch.Name = name
+		runName := towerRunName(nfLaunch)
+		if !pattern.MatchString(runName) || len(runName) > 80 {
+			t.Errorf("invalid run name %q for launch %q", runName, name)
+		}
+	}
+
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Tower = &batchv1alpha1.NextflowLaunchTower{
+			AccessTokenSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "seqera"},
+				Key:                  "token",
+			},
+		}
+	})
+	nfLaunch.Status.Tower = &batchv1alpha1.NextflowLaunchTowerStatus{RunName: towerRunName(nfLaunch)}
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	driver := pod.Spec.Containers[0]
+	if command := strings.Join(driver.Command, " "); !strings.Contains(command, "-name $(TOWER_RUN_NAME) ") {
+		t.Errorf("run name not in command %q", command)
+	}
+	if containerEnv(driver)["TOWER_RUN_NAME"] != nfLaunch.Status.Tower.RunName {
+		t.Errorf("run name not in environment: %+v", driver.Env)
+	}
+
+	nfLaunch.Spec.Nextflow.Command = []string{"sh", "-c"}
+	nfLaunch.Spec.Nextflow.Args = []string{"nextflow run hello"}
+	pod, _ = makeNextflowPod(nfLaunch, "config")
+	if args := pod.Spec.Containers[0].Args; len(args) != 1 {
+		t.Errorf("arguments of a custom command changed: %q", args)
+	}
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package controllers
 
 import (
-	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
+
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
 )
 
 // Generate a hexadecimal hash of the specified length
@@ -41,6 +43,16 @@ func keyIsEmpty(x map[string]string, key string) bool {
 	return false
 }
 
+// Keys of a map of strings, in alphabetical order
+func sortedKeys(x map[string]string) []string {
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Escape "unsafe" characters in a string
 func escape(s string) string {
 	s = strings.ReplaceAll(s, "'", "\\'")
@@ -48,32 +60,29 @@ func escape(s string) string {
 	return strings.ReplaceAll(s, ";", "\\;")
 }
 
-// Safely parse a string as a Groovy value
+// Parse a string as a Groovy value
 // (If it's a boolean true/false value, it won't be quoted)
-func groovyValue(s string) string {
+func groovyValue(s string) groovy.Value {
 	if s == "true" || s == "false" {
-		return s
-	} else {
-		return "'" + escape(s) + "'"
+		return groovy.Bool(s == "true")
 	}
+	return groovy.String(s)
 }
 
-// Parse a map of strings.
-// If one of the values equals to "(map)", return a Groovy-formatted
-// named map: "key: [key1: "value1", key2: "value2", ...]".
-// Otherwise return a comma-separated list of key-value pairs
-func stringsOrMap(x map[string]string) string {
-	key := ""
-	out := ""
+// Parse a map of strings as Groovy values
+func groovyValues(x map[string]string) map[string]groovy.Value {
+	values := map[string]groovy.Value{}
 	for k, v := range x {
-		if v == "(map)" {
-			key = k
-		} else {
-			out = out + fmt.Sprintf("%s: '%s', ", escape(k), escape(v))
-		}
+		values[k] = groovyValue(v)
 	}
-	if key != "" {
-		out = fmt.Sprintf("%s: [%s], ", escape(key), out)
+	return values
+}
+
+// Convert a map of strings to Groovy strings
+func groovyStrings(x map[string]string) map[string]groovy.Value {
+	values := map[string]groovy.Value{}
+	for k, v := range x {
+		values[k] = groovy.String(v)
 	}
-	return out
+	return values
 }
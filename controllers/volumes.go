@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+	"mnmdiagnostics/nextflow-k8s-operator/internal/groovy"
+)
+
+// Name of the i-th additional volume in the driver pod
+func volumeName(i int) string {
+	return fmt.Sprintf("nextflow-volume-%d", i)
+}
+
+// Check the volumes section of a launch definition; paths used by the
+// operator itself cannot be mount points
+func validateVolumes(spec batchv1alpha1.NextflowLaunchSpec) error {
+	reserved := map[string]bool{
+		spec.K8s["storageMountPath"]: true,
+		configPath:                   true,
+		configFromPath:               true,
+		secretsPath:                  true,
+		secretsSourcePath:            true,
+		scriptPath:                   true,
+	}
+	for i, volume := range spec.Volumes {
+		if !path.IsAbs(volume.MountPath) {
+			return fmt.Errorf("spec.volumes[%d].mountPath must be an absolute path", i)
+		}
+		if reserved[path.Clean(volume.MountPath)] {
+			return fmt.Errorf("spec.volumes[%d].mountPath %s is already in use", i, volume.MountPath)
+		}
+		reserved[path.Clean(volume.MountPath)] = true
+
+		sources := 0
+		for _, set := range []bool{
+			volume.PersistentVolumeClaim != nil, volume.ConfigMap != nil, volume.Secret != nil,
+			volume.NFS != nil, volume.CSI != nil, volume.EmptyDir != nil,
+		} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("spec.volumes[%d] requires exactly one of persistentVolumeClaim, configMap, secret, nfs, csi and emptyDir", i)
+		}
+		if volume.NFS != nil && !volume.DriverOnly {
+			// Nextflow has no pod option for NFS volumes
+			return fmt.Errorf("spec.volumes[%d]: nfs volumes cannot be mounted in the workers, set driverOnly", i)
+		}
+		if volume.SubPath != "" && !volume.DriverOnly && volume.PersistentVolumeClaim == nil {
+			return fmt.Errorf("spec.volumes[%d].subPath is only supported for persistentVolumeClaim in the workers", i)
+		}
+	}
+	return nil
+}
+
+// Volume source of a volume definition, for the driver pod
+func volumeSource(volume batchv1alpha1.NextflowLaunchVolume) corev1.VolumeSource {
+	switch {
+	case volume.PersistentVolumeClaim != nil:
+		return corev1.VolumeSource{PersistentVolumeClaim: volume.PersistentVolumeClaim.DeepCopy()}
+	case volume.ConfigMap != nil:
+		return corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: *volume.ConfigMap}}
+	case volume.Secret != nil:
+		return corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: volume.Secret.Name}}
+	case volume.NFS != nil:
+		return corev1.VolumeSource{NFS: volume.NFS.DeepCopy()}
+	case volume.CSI != nil:
+		return corev1.VolumeSource{CSI: volume.CSI.DeepCopy()}
+	default:
+		return corev1.VolumeSource{EmptyDir: volume.EmptyDir.DeepCopy()}
+	}
+}
+
+// Attach the additional volumes to the driver pod
+func attachVolumes(pod *corev1.Pod, volumes []batchv1alpha1.NextflowLaunchVolume) {
+	for i, volume := range volumes {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         volumeName(i),
+			VolumeSource: volumeSource(volume),
+		})
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName(i),
+			MountPath: volume.MountPath,
+			SubPath:   volume.SubPath,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+}
+
+// Pod options mounting the additional volumes in the workers
+func volumePodOptions(volumes []batchv1alpha1.NextflowLaunchVolume) (groovy.List, error) {
+	options := groovy.List{}
+	for _, volume := range volumes {
+		if volume.DriverOnly {
+			continue
+		}
+		mountPath := groovy.MapEntry{Key: "mountPath", Value: groovy.String(volume.MountPath)}
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			option := groovy.Map{
+				{Key: "volumeClaim", Value: groovy.String(volume.PersistentVolumeClaim.ClaimName)},
+				mountPath,
+			}
+			if volume.SubPath != "" {
+				option = append(option, groovy.MapEntry{Key: "subPath", Value: groovy.String(volume.SubPath)})
+			}
+			if volume.ReadOnly || volume.PersistentVolumeClaim.ReadOnly {
+				option = append(option, groovy.MapEntry{Key: "readOnly", Value: groovy.Bool(true)})
+			}
+			options = append(options, option)
+		case volume.ConfigMap != nil:
+			options = append(options, groovy.Map{{Key: "config", Value: groovy.String(volume.ConfigMap.Name)}, mountPath})
+		case volume.Secret != nil:
+			options = append(options, groovy.Map{{Key: "secret", Value: groovy.String(volume.Secret.Name)}, mountPath})
+		case volume.CSI != nil:
+			csi := volume.CSI.DeepCopy()
+			if volume.ReadOnly {
+				readOnly := true
+				csi.ReadOnly = &readOnly
+			}
+			value, err := objectValue(csi)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, groovy.Map{{Key: "csi", Value: value}, mountPath})
+		case volume.EmptyDir != nil:
+			value, err := objectValue(volume.EmptyDir)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, groovy.Map{{Key: "emptyDir", Value: value}, mountPath})
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	batchv1alpha1 "mnmdiagnostics/nextflow-k8s-operator/api/v1alpha1"
+)
+
+func TestVolumes(t *testing.T) {
+	nfLaunch := validTestLaunch(t, func(spec *batchv1alpha1.NextflowLaunchSpec) {
+		spec.Volumes = []batchv1alpha1.NextflowLaunchVolume{
+			{
+				MountPath: "/references",
+				ReadOnly:  true,
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "genomes",
+				},
+			},
+			{
+				MountPath: "/scratch",
+				EmptyDir:  &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+			},
+			{
+				MountPath:  "/archive",
+				DriverOnly: true,
+				NFS:        &corev1.NFSVolumeSource{Server: "nas", Path: "/export"},
+			},
+		}
+	})
+
+	pod, _ := makeNextflowPod(nfLaunch, "config")
+	mounts := map[string]corev1.VolumeMount{}
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		mounts[mount.MountPath] = mount
+	}
+	for _, mountPath := range []string{"/references", "/scratch", "/archive"} {
+		if _, ok := mounts[mountPath]; !ok {
+			t.Errorf("%s is not mounted in the driver", mountPath)
+		}
+	}
+	if !mounts["/references"].ReadOnly {
+		t.Error("/references is writable in the driver")
+	}
+
+	testConfigContains(t, testConfig(t, nfLaunch),
+		`pod = [[volumeClaim: 'genomes', mountPath: '/references', readOnly: true], `+
+			`[emptyDir: [medium: 'Memory'], mountPath: '/scratch']]`)
+
+	volume := func(volume batchv1alpha1.NextflowLaunchVolume) func(*batchv1alpha1.NextflowLaunchSpec) {
+		return func(spec *batchv1alpha1.NextflowLaunchSpec) {
+			spec.Volumes = []batchv1alpha1.NextflowLaunchVolume{volume}
+		}
+	}
+	testRejected(t, specChanges{
+		"nfs volume for the workers": volume(batchv1alpha1.NextflowLaunchVolume{
+			MountPath: "/archive",
+			NFS:       &corev1.NFSVolumeSource{Server: "nas", Path: "/export"},
+		}),
+		"volume over the storage mount": volume(batchv1alpha1.NextflowLaunchVolume{
+			MountPath: defaultMountPath,
+			EmptyDir:  &corev1.EmptyDirVolumeSource{},
+		}),
+		"volume without a source": volume(batchv1alpha1.NextflowLaunchVolume{MountPath: "/scratch"}),
+	})
+}
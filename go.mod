@@ -1,6 +1,6 @@
 module mnmdiagnostics/nextflow-k8s-operator
 
-go 1.17
+go 1.18
 
 require (
 	github.com/onsi/ginkgo v1.16.5
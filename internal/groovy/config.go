@@ -0,0 +1,204 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groovy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type itemKind int
+
+const (
+	assignment itemKind = iota
+	scope
+	selector
+	include
+	call
+)
+
+type item struct {
+	kind  itemKind
+	key   string
+	value Value
+	block *Block
+}
+
+// Block is a config scope: an ordered list of settings, nested scopes,
+// process selectors and included files. The zero value is an empty block
+type Block struct {
+	items []*item
+}
+
+// NewBlock returns an empty block
+func NewBlock() *Block {
+	return &Block{}
+}
+
+// Find an item of the given kind and key
+func (b *Block) find(kind itemKind, key string) *item {
+	for _, it := range b.items {
+		if it.kind == kind && it.key == key {
+			return it
+		}
+	}
+	return nil
+}
+
+// Set assigns a value to a setting, replacing any earlier assignment.
+// The name may be a dotted path of identifiers (e.g. "ext.args")
+func (b *Block) Set(key string, value Value) *Block {
+	if it := b.find(assignment, key); it != nil {
+		it.value = value
+		return b
+	}
+	b.items = append(b.items, &item{kind: assignment, key: key, value: value})
+	return b
+}
+
+// SetAll assigns all the values of a map, in the order of the keys
+func (b *Block) SetAll(values map[string]Value) *Block {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.Set(key, values[key])
+	}
+	return b
+}
+
+// Scope returns the nested scope with the given name, creating it if needed
+func (b *Block) Scope(name string) *Block {
+	if it := b.find(scope, name); it != nil {
+		return it.block
+	}
+	it := &item{kind: scope, key: name, block: &Block{}}
+	b.items = append(b.items, it)
+	return it.block
+}
+
+// Selector returns a process selector block, e.g. withName: 'FOO' { ... },
+// creating it if needed
+func (b *Block) Selector(kind string, pattern string) *Block {
+	key := kind + "\x00" + pattern
+	if it := b.find(selector, key); it != nil {
+		return it.block
+	}
+	it := &item{kind: selector, key: key, block: &Block{}}
+	b.items = append(b.items, it)
+	return it.block
+}
+
+// Include appends an includeConfig statement for the given path
+func (b *Block) Include(path string) *Block {
+	b.items = append(b.items, &item{kind: include, key: path})
+	return b
+}
+
+// Call appends a method call with a single argument, e.g. id 'nf-prov'
+// in the plugins scope
+func (b *Block) Call(method string, arg Value) *Block {
+	b.items = append(b.items, &item{kind: call, key: method, value: arg})
+	return b
+}
+
+// Empty reports whether the block contains no items
+func (b *Block) Empty() bool {
+	return len(b.items) == 0
+}
+
+// Render serializes the block as the contents of a config file
+func (b *Block) Render() (string, error) {
+	var out strings.Builder
+	err := b.write(&out, 0)
+	if err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (b *Block) write(out *strings.Builder, depth int) error {
+	indent := strings.Repeat(indentation, depth)
+	for _, it := range b.items {
+		switch it.kind {
+		case assignment:
+			err := target(it.key)
+			if err != nil {
+				return err
+			}
+			if it.value == nil {
+				return fmt.Errorf("%q has no value", it.key)
+			}
+			out.WriteString(indent + it.key + " = ")
+			err = it.value.write(out, depth)
+			if err != nil {
+				return fmt.Errorf("%s: %w", it.key, err)
+			}
+			out.WriteString("\n")
+
+		case scope, selector:
+			header, err := blockHeader(it)
+			if err != nil {
+				return err
+			}
+			out.WriteString(indent + header + " {\n")
+			err = it.block.write(out, depth+1)
+			if err != nil {
+				return err
+			}
+			out.WriteString(indent + "}\n")
+
+		case call:
+			if !IsIdentifier(it.key) {
+				return fmt.Errorf("%q is not a valid method name", it.key)
+			}
+			out.WriteString(indent + it.key + " ")
+			err := it.value.write(out, depth)
+			if err != nil {
+				return fmt.Errorf("%s: %w", it.key, err)
+			}
+			out.WriteString("\n")
+
+		case include:
+			path, err := Quote(it.key)
+			if err != nil {
+				return err
+			}
+			out.WriteString(indent + "includeConfig " + path + "\n")
+		}
+	}
+	return nil
+}
+
+// The opening line of a scope or selector block (without the brace)
+func blockHeader(it *item) (string, error) {
+	if it.kind == scope {
+		return name(it.key)
+	}
+	parts := strings.SplitN(it.key, "\x00", 2)
+	if !IsIdentifier(parts[0]) {
+		return "", fmt.Errorf("%q is not a valid selector", parts[0])
+	}
+	pattern, err := Quote(parts[1])
+	if err != nil {
+		return "", err
+	}
+	return parts[0] + ": " + pattern, nil
+}
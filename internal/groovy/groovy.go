@@ -0,0 +1,275 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package groovy models Nextflow configuration files and serializes them
+// as Groovy source. The output is deterministic (items are written in the
+// order they were added, maps are sorted by key) and all strings are written
+// as single-quoted Groovy literals, which are never interpolated.
+package groovy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const indentation = "    "
+
+// Groovy identifiers may contain $, as in Nextflow's executor-specific
+// scopes, e.g. executor.$k8s.queueSize
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Groovy keywords cannot be used as bare identifiers
+var keywords = map[string]bool{
+	"abstract": true, "as": true, "assert": true, "boolean": true, "break": true,
+	"byte": true, "case": true, "catch": true, "char": true, "class": true,
+	"const": true, "continue": true, "def": true, "default": true, "do": true,
+	"double": true, "else": true, "enum": true, "extends": true, "false": true,
+	"final": true, "finally": true, "float": true, "for": true, "goto": true,
+	"if": true, "implements": true, "import": true, "in": true, "instanceof": true,
+	"int": true, "interface": true, "long": true, "native": true, "new": true,
+	"null": true, "package": true, "private": true, "protected": true,
+	"public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true,
+	"threadsafe": true, "throw": true, "throws": true, "trait": true,
+	"transient": true, "true": true, "try": true, "var": true, "void": true,
+	"volatile": true, "while": true,
+}
+
+// Value is anything that can be assigned in a config file
+type Value interface {
+	write(b *strings.Builder, depth int) error
+}
+
+// String is written as a single-quoted string literal
+type String string
+
+// Bool is written as true/false
+type Bool bool
+
+// Int is written as an integer literal
+type Int int64
+
+// Float is written as a decimal literal
+type Float float64
+
+// List is written as a Groovy list: [a, b, c]
+type List []Value
+
+// Map is written as a Groovy map: [key: value, ...], in the order of entries
+type Map []MapEntry
+
+// MapEntry is a single key-value pair of a Map
+type MapEntry struct {
+	Key   string
+	Value Value
+}
+
+// IsIdentifier reports whether s can be used as a bare Groovy identifier
+func IsIdentifier(s string) bool {
+	return identifierPattern.MatchString(s) && !keywords[s]
+}
+
+// Quote returns s as a single-quoted Groovy string literal
+func Quote(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("string %q is not valid UTF-8", s)
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f || r == 0x2028 || r == 0x2029 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+	return b.String(), nil
+}
+
+// Format a map key or a block name, quoting it unless it is an identifier
+func name(s string) (string, error) {
+	if IsIdentifier(s) {
+		return s, nil
+	}
+	return Quote(s)
+}
+
+// Check an assignment target; dotted paths of identifiers are allowed
+func target(s string) error {
+	for _, part := range strings.Split(s, ".") {
+		if !IsIdentifier(part) {
+			return fmt.Errorf("%q is not a valid config setting name", s)
+		}
+	}
+	return nil
+}
+
+func (v String) write(b *strings.Builder, depth int) error {
+	quoted, err := Quote(string(v))
+	if err != nil {
+		return err
+	}
+	b.WriteString(quoted)
+	return nil
+}
+
+func (v Bool) write(b *strings.Builder, depth int) error {
+	b.WriteString(strconv.FormatBool(bool(v)))
+	return nil
+}
+
+func (v Int) write(b *strings.Builder, depth int) error {
+	b.WriteString(strconv.FormatInt(int64(v), 10))
+	return nil
+}
+
+func (v Float) write(b *strings.Builder, depth int) error {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%v cannot be written as a number", f)
+	}
+	b.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	return nil
+}
+
+func (v List) write(b *strings.Builder, depth int) error {
+	b.WriteByte('[')
+	for i, item := range v {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if item == nil {
+			return fmt.Errorf("list item %d has no value", i)
+		}
+		err := item.write(b, depth)
+		if err != nil {
+			return err
+		}
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+func (v Map) write(b *strings.Builder, depth int) error {
+	if len(v) == 0 {
+		b.WriteString("[:]")
+		return nil
+	}
+	b.WriteByte('[')
+	for i, entry := range v {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		key, err := name(entry.Key)
+		if err != nil {
+			return err
+		}
+		if entry.Value == nil {
+			return fmt.Errorf("map key %q has no value", entry.Key)
+		}
+		b.WriteString(key)
+		b.WriteString(": ")
+		err = entry.Value.write(b, depth)
+		if err != nil {
+			return err
+		}
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+// SortedMap builds a Map from a Go map, sorted by key
+func SortedMap(values map[string]Value) Map {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	m := make(Map, 0, len(keys))
+	for _, key := range keys {
+		m = append(m, MapEntry{Key: key, Value: values[key]})
+	}
+	return m
+}
+
+// ValueOf converts a decoded JSON value (maps, slices, strings, numbers,
+// booleans) into a config value; maps are sorted by key
+func ValueOf(x interface{}) (Value, error) {
+	switch v := x.(type) {
+	case Value:
+		return v, nil
+	case string:
+		return String(v), nil
+	case bool:
+		return Bool(v), nil
+	case int:
+		return Int(v), nil
+	case int32:
+		return Int(v), nil
+	case int64:
+		return Int(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return Int(v), nil
+		}
+		return Float(v), nil
+	case []interface{}:
+		list := make(List, 0, len(v))
+		for _, item := range v {
+			value, err := ValueOf(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case map[string]interface{}:
+		values := map[string]Value{}
+		for key, item := range v {
+			value, err := ValueOf(item)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		}
+		return SortedMap(values), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a config value", x)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groovy
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Decode a single-quoted Groovy string literal, as the Groovy lexer would
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", errors.New("not a single-quoted literal")
+	}
+	body := s[1 : len(s)-1]
+	var out strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch c {
+		case '\'', '\n', '\r':
+			return "", errors.New("unescaped character in literal")
+		case '\\':
+			i++
+			if i == len(body) {
+				return "", errors.New("dangling backslash")
+			}
+			switch body[i] {
+			case '\\':
+				out.WriteByte('\\')
+			case '\'':
+				out.WriteByte('\'')
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case 'b':
+				out.WriteByte('\b')
+			case 'f':
+				out.WriteByte('\f')
+			case 'u':
+				if i+4 >= len(body) {
+					return "", errors.New("short unicode escape")
+				}
+				r, err := strconv.ParseUint(body[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", err
+				}
+				out.WriteRune(rune(r))
+				i += 4
+			default:
+				return "", errors.New("unknown escape")
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), nil
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"plain":        `'plain'`,
+		"it's":         `'it\'s'`,
+		`C:\data`:      `'C:\\data'`,
+		"${HOME}/data": `'${HOME}/data'`,
+		"two\nlines":   `'two\nlines'`,
+		"bell\a":       `'bell\u0007'`,
+		`"quoted"`:     `'"quoted"'`,
+	}
+	for in, want := range cases {
+		got, err := Quote(in)
+		if err != nil {
+			t.Fatalf("Quote(%q): %v", in, err)
+		}
+		if got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+	if _, err := Quote("\xff"); err == nil {
+		t.Error("Quote accepted invalid UTF-8")
+	}
+}
+
+func TestRender(t *testing.T) {
+	config := NewBlock()
+	process := config.Scope("process")
+	process.Set("executor", String("k8s"))
+	process.Set("pod", List{
+		Map{{"label", String("app")}, {"value", String("nextflow")}},
+		Map{{"toleration", SortedMap(map[string]Value{
+			"key":    String("spot"),
+			"effect": String("NoSchedule"),
+		})}},
+	})
+	process.Selector("withLabel", "big|huge").Set("memory", String("64 GB"))
+	config.Scope("params").SetAll(map[string]Value{
+		"outdir":  String("/data/$run"),
+		"dry_run": Bool(false),
+		"reads":   Int(2),
+	})
+	config.Scope("profiles").Scope("my-site").Scope("params").Set("site", String("x"))
+	config.Scope("plugins").Call("id", String("nf-prov@1.2.0"))
+	config.Include("/etc/nextflow/site.config")
+	config.Set("executor.$k8s.queueSize", Int(100))
+
+	got, err := config.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `process {
+    executor = 'k8s'
+    pod = [[label: 'app', value: 'nextflow'], [toleration: [effect: 'NoSchedule', key: 'spot']]]
+    withLabel: 'big|huge' {
+        memory = '64 GB'
+    }
+}
+params {
+    dry_run = false
+    outdir = '/data/$run'
+    reads = 2
+}
+profiles {
+    'my-site' {
+        params {
+            site = 'x'
+        }
+    }
+}
+plugins {
+    id 'nf-prov@1.2.0'
+}
+includeConfig '/etc/nextflow/site.config'
+executor.$k8s.queueSize = 100
+`
+	if got != want {
+		t.Errorf("unexpected config:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, key := range []string{"my-param", "class", "1st", "a..b", ""} {
+		_, err := NewBlock().Set(key, String("x")).Render()
+		if err == nil {
+			t.Errorf("setting %q was accepted", key)
+		}
+	}
+	if _, err := NewBlock().Set("x", Float(1.0/zero())).Render(); err == nil {
+		t.Error("infinite number was accepted")
+	}
+}
+
+func zero() float64 { return 0 }
+
+func TestValueOf(t *testing.T) {
+	value, err := ValueOf(map[string]interface{}{
+		"runAsUser": float64(1000),
+		"sysctls":   []interface{}{map[string]interface{}{"name": "a", "value": "b"}},
+		"ratio":     0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewBlock().Set("x", value).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "x = [ratio: 0.5, runAsUser: 1000, sysctls: [[name: 'a', value: 'b']]]\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func FuzzQuote(f *testing.F) {
+	for _, seed := range []string{"", "it's", `back\slash`, "${x}", "a\nb\r\tc", "\u2028", "\x00"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		quoted, err := Quote(s)
+		if !utf8.ValidString(s) {
+			if err == nil {
+				t.Fatalf("Quote(%q) accepted invalid UTF-8", s)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("Quote(%q): %v", s, err)
+		}
+		back, err := unquote(quoted)
+		if err != nil {
+			t.Fatalf("Quote(%q) = %s is not a valid literal: %v", s, quoted, err)
+		}
+		if back != s {
+			t.Fatalf("Quote(%q) = %s round-trips to %q", s, quoted, back)
+		}
+	})
+}
+
+func FuzzRender(f *testing.F) {
+	f.Add("outdir", "/data/${run}", "withName", "FOO|BAR")
+	f.Add("my-param", "it's", "withLabel", "'")
+	f.Fuzz(func(t *testing.T, key string, value string, kind string, pattern string) {
+		build := func() *Block {
+			config := NewBlock()
+			config.Scope("params").SetAll(map[string]Value{
+				key:       String(value),
+				key + "2": String(value),
+			})
+			config.Scope("process").Selector(kind, pattern).Set("memory", String(value))
+			config.Scope("env").Set(key, Map{{key, String(value)}})
+			return config
+		}
+		first, err1 := build().Render()
+		second, err2 := build().Render()
+		if (err1 == nil) != (err2 == nil) {
+			t.Fatalf("inconsistent errors: %v, %v", err1, err2)
+		}
+		if first != second {
+			t.Fatalf("non-deterministic output:\n%s\n%s", first, second)
+		}
+		if err1 != nil {
+			return
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(first, "\n"), "\n") {
+			if strings.TrimSpace(line) == "" {
+				t.Fatalf("value leaked a line break:\n%s", first)
+			}
+		}
+	})
+}
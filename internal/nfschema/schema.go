@@ -0,0 +1,255 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nfschema validates pipeline parameters against the subset of
+// JSON Schema used by nextflow_schema.json files (nf-core style)
+package nfschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Property describes a single pipeline parameter
+type Property struct {
+	Types   []string
+	Enum    []string
+	Pattern *regexp.Regexp
+	Minimum *float64
+	Maximum *float64
+	Default *string
+}
+
+// Schema holds the parameters declared by a pipeline
+type Schema struct {
+	Properties map[string]*Property
+	Required   []string
+}
+
+// Error reports an invalid or missing parameter
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Param + ": " + e.Message
+}
+
+// raw JSON form of a schema (or of a group of parameters)
+type rawSchema struct {
+	Properties  map[string]rawProperty `json:"properties"`
+	Required    []string               `json:"required"`
+	Definitions map[string]rawSchema   `json:"definitions"`
+	Defs        map[string]rawSchema   `json:"$defs"`
+	AllOf       []struct {
+		Ref string `json:"$ref"`
+	} `json:"allOf"`
+}
+
+type rawProperty struct {
+	Type    json.RawMessage `json:"type"`
+	Enum    []interface{}   `json:"enum"`
+	Pattern string          `json:"pattern"`
+	Minimum *float64        `json:"minimum"`
+	Maximum *float64        `json:"maximum"`
+	Default interface{}     `json:"default"`
+}
+
+// Parse reads a nextflow_schema.json file. Parameters are collected from the
+// top-level properties and from the groups referenced in allOf
+func Parse(data []byte) (*Schema, error) {
+	var raw rawSchema
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	schema := &Schema{Properties: map[string]*Property{}}
+	groups := []rawSchema{raw}
+	for _, ref := range raw.AllOf {
+		var group rawSchema
+		var ok bool
+		switch {
+		case strings.HasPrefix(ref.Ref, "#/definitions/"):
+			group, ok = raw.Definitions[strings.TrimPrefix(ref.Ref, "#/definitions/")]
+		case strings.HasPrefix(ref.Ref, "#/$defs/"):
+			group, ok = raw.Defs[strings.TrimPrefix(ref.Ref, "#/$defs/")]
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid schema: unresolved reference %q", ref.Ref)
+		}
+		groups = append(groups, group)
+	}
+	for _, group := range groups {
+		for name, raw := range group.Properties {
+			property, err := parseProperty(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema: %s: %w", name, err)
+			}
+			schema.Properties[name] = property
+		}
+		schema.Required = append(schema.Required, group.Required...)
+	}
+	sort.Strings(schema.Required)
+	return schema, nil
+}
+
+func parseProperty(raw rawProperty) (*Property, error) {
+	property := &Property{Minimum: raw.Minimum, Maximum: raw.Maximum}
+	if len(raw.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(raw.Type, &single); err == nil {
+			property.Types = []string{single}
+		} else if err := json.Unmarshal(raw.Type, &property.Types); err != nil {
+			return nil, fmt.Errorf("invalid type %s", raw.Type)
+		}
+	}
+	for _, value := range raw.Enum {
+		property.Enum = append(property.Enum, format(value))
+	}
+	if raw.Pattern != "" {
+		// JavaScript patterns not supported by Go (e.g. lookarounds) are skipped
+		if pattern, err := regexp.Compile(raw.Pattern); err == nil {
+			property.Pattern = pattern
+		}
+	}
+	if raw.Default != nil {
+		value := format(raw.Default)
+		property.Default = &value
+	}
+	return property, nil
+}
+
+// String form of a JSON value, as it would be given in spec.params
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// Validate checks the params and returns them completed with the defaults
+// of the parameters which were not set, along with the errors found
+func (s *Schema) Validate(params map[string]string) (map[string]string, []Error) {
+	effective := map[string]string{}
+	var errs []Error
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		effective[name] = params[name]
+		property, ok := s.Properties[name]
+		if !ok {
+			// not declared: may be used by the config rather than the pipeline
+			continue
+		}
+		if message := property.check(params[name]); message != "" {
+			errs = append(errs, Error{Param: name, Message: message})
+		}
+	}
+
+	for name, property := range s.Properties {
+		if _, ok := effective[name]; !ok && property.Default != nil {
+			effective[name] = *property.Default
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := effective[name]; !ok {
+			errs = append(errs, Error{Param: name, Message: "is required"})
+		}
+	}
+	return effective, errs
+}
+
+// Check a single value, return an error message or an empty string
+func (p *Property) check(value string) string {
+	if len(p.Types) > 0 {
+		matched := false
+		for _, t := range p.Types {
+			matched = matched || matchesType(t, value)
+		}
+		if !matched {
+			return fmt.Sprintf("%q is not of type %s", value, strings.Join(p.Types, " or "))
+		}
+	}
+	if len(p.Enum) > 0 {
+		found := false
+		for _, option := range p.Enum {
+			found = found || option == value || numericEqual(option, value)
+		}
+		if !found {
+			return fmt.Sprintf("%q is not one of: %s", value, strings.Join(p.Enum, ", "))
+		}
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(value) {
+		return fmt.Sprintf("%q does not match pattern %s", value, p.Pattern)
+	}
+	if p.Minimum != nil || p.Maximum != nil {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+		if p.Minimum != nil && number < *p.Minimum {
+			return fmt.Sprintf("%s is less than the minimum of %g", value, *p.Minimum)
+		}
+		if p.Maximum != nil && number > *p.Maximum {
+			return fmt.Sprintf("%s is greater than the maximum of %g", value, *p.Maximum)
+		}
+	}
+	return ""
+}
+
+func matchesType(t string, value string) bool {
+	switch t {
+	case "integer":
+		number, err := strconv.ParseFloat(value, 64)
+		return err == nil && number == math.Trunc(number)
+	case "number":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case "boolean":
+		return value == "true" || value == "false"
+	case "null":
+		return value == "" || value == "null"
+	default:
+		// strings, and types which cannot be given as a single value
+		return true
+	}
+}
+
+func numericEqual(a string, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+    "$schema": "http://json-schema.org/draft-07/schema",
+    "type": "object",
+    "definitions": {
+        "input_output_options": {
+            "type": "object",
+            "required": ["input", "outdir"],
+            "properties": {
+                "input": {"type": "string", "pattern": "^\\S+\\.csv$"},
+                "outdir": {"type": "string"}
+            }
+        },
+        "alignment_options": {
+            "type": "object",
+            "properties": {
+                "aligner": {"type": "string", "enum": ["star", "hisat2"], "default": "star"},
+                "min_mapped_reads": {"type": "number", "default": 5, "minimum": 0, "maximum": 100},
+                "max_cpus": {"type": "integer", "default": 16},
+                "skip_qc": {"type": "boolean", "default": false},
+                "save_unaligned": {"type": "boolean"}
+            }
+        }
+    },
+    "allOf": [
+        {"$ref": "#/definitions/input_output_options"},
+        {"$ref": "#/definitions/alignment_options"}
+    ]
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	effective, errs := schema.Validate(map[string]string{
+		"input":    "samples.csv",
+		"outdir":   "results",
+		"max_cpus": "8",
+		"custom":   "anything",
+	})
+	if len(errs) > 0 {
+		t.Fatalf("valid params rejected: %v", errs)
+	}
+	want := map[string]string{
+		"input":            "samples.csv",
+		"outdir":           "results",
+		"max_cpus":         "8",
+		"custom":           "anything",
+		"aligner":          "star",
+		"min_mapped_reads": "5",
+		"skip_qc":          "false",
+	}
+	if !reflect.DeepEqual(effective, want) {
+		t.Errorf("effective params are %v, want %v", effective, want)
+	}
+
+	_, errs = schema.Validate(map[string]string{
+		"input":            "samples.tsv",
+		"aligner":          "bowtie",
+		"min_mapped_reads": "500",
+		"max_cpus":         "2.5",
+		"skip_qc":          "yes",
+	})
+	var params []string
+	for _, err := range errs {
+		params = append(params, err.Param)
+	}
+	wantParams := []string{"aligner", "input", "max_cpus", "min_mapped_reads", "skip_qc", "outdir"}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("errors reported for %v, want %v: %v", params, wantParams, errs)
+	}
+}
+
+func TestParse(t *testing.T) {
+	schema, err := Parse([]byte(`{
+        "$defs": {"options": {"properties": {"n": {"type": ["integer", "null"], "enum": [1, 2]}}}},
+        "allOf": [{"$ref": "#/$defs/options"}],
+        "properties": {"top": {"type": "string", "pattern": "(?=lookahead)"}}
+    }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := schema.Properties["n"]; n == nil || !reflect.DeepEqual(n.Types, []string{"integer", "null"}) {
+		t.Errorf("unexpected property n: %+v", n)
+	}
+	if top := schema.Properties["top"]; top == nil || top.Pattern != nil {
+		t.Errorf("unsupported pattern was not skipped: %+v", top)
+	}
+	if _, errs := schema.Validate(map[string]string{"n": "2.0"}); len(errs) > 0 {
+		t.Errorf("numeric enum value rejected: %v", errs)
+	}
+
+	for _, invalid := range []string{
+		`not json`,
+		`{"allOf": [{"$ref": "#/definitions/missing"}]}`,
+		`{"properties": {"x": {"type": 5}}}`,
+	} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("invalid schema %s was accepted", invalid)
+		}
+	}
+}
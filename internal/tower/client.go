@@ -0,0 +1,171 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tower is a minimal client of the Seqera Platform (Tower) API,
+// used to find the runs started by the operator
+package tower
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the API endpoint of the hosted Seqera Platform
+const DefaultEndpoint = "https://api.cloud.seqera.io"
+
+// ErrNotFound is returned when the run is not (yet) known to the platform
+var ErrNotFound = errors.New("run not found")
+
+// Client talks to a Seqera Platform API endpoint
+type Client struct {
+	Endpoint string
+	Token    string
+	HTTP     *http.Client
+}
+
+// Run identifies a pipeline run in Seqera Platform
+type Run struct {
+	ID  string
+	URL string
+}
+
+// NewClient returns a client for the given endpoint and access token
+func NewClient(endpoint string, token string) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return &Client{
+		Endpoint: strings.TrimRight(endpoint, "/"),
+		Token:    token,
+		HTTP:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send a GET request and decode the JSON response
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := c.Endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// FindRun looks up a run by its name and returns its ID and web URL
+func (c *Client) FindRun(ctx context.Context, workspaceID string, runName string) (*Run, error) {
+
+	query := url.Values{"search": {runName}}
+	if workspaceID != "" {
+		query.Set("workspaceId", workspaceID)
+	}
+	var workflows struct {
+		Workflows []struct {
+			Workflow struct {
+				ID      string `json:"id"`
+				RunName string `json:"runName"`
+			} `json:"workflow"`
+		} `json:"workflows"`
+	}
+	err := c.get(ctx, "/workflow", query, &workflows)
+	if err != nil {
+		return nil, err
+	}
+	id := ""
+	for _, w := range workflows.Workflows {
+		if w.Workflow.RunName == runName {
+			id = w.Workflow.ID
+			break
+		}
+	}
+	if id == "" {
+		return nil, ErrNotFound
+	}
+
+	prefix, err := c.runPrefix(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return &Run{ID: id, URL: prefix + "/watch/" + id}, nil
+}
+
+// Web URL under which the runs of a workspace (or of the user) are shown
+func (c *Client) runPrefix(ctx context.Context, workspaceID string) (string, error) {
+
+	var userInfo struct {
+		User struct {
+			ID       int64  `json:"id"`
+			UserName string `json:"userName"`
+		} `json:"user"`
+	}
+	err := c.get(ctx, "/user-info", nil, &userInfo)
+	if err != nil {
+		return "", err
+	}
+	if workspaceID == "" {
+		return c.webURL() + "/user/" + url.PathEscape(userInfo.User.UserName), nil
+	}
+
+	var workspaces struct {
+		OrgsAndWorkspaces []struct {
+			OrgName       string `json:"orgName"`
+			WorkspaceID   *int64 `json:"workspaceId"`
+			WorkspaceName string `json:"workspaceName"`
+		} `json:"orgsAndWorkspaces"`
+	}
+	err = c.get(ctx, fmt.Sprintf("/user/%d/workspaces", userInfo.User.ID), nil, &workspaces)
+	if err != nil {
+		return "", err
+	}
+	for _, w := range workspaces.OrgsAndWorkspaces {
+		if w.WorkspaceID != nil && fmt.Sprint(*w.WorkspaceID) == workspaceID {
+			return c.webURL() + "/orgs/" + url.PathEscape(w.OrgName) +
+				"/workspaces/" + url.PathEscape(w.WorkspaceName), nil
+		}
+	}
+	return "", fmt.Errorf("workspace %s not found", workspaceID)
+}
+
+// Web interface URL corresponding to the API endpoint
+// (https://api.example.com or https://example.com/api -> https://example.com)
+func (c *Client) webURL() string {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return c.Endpoint
+	}
+	u.Host = strings.TrimPrefix(u.Host, "api.")
+	u.Path = strings.TrimSuffix(strings.TrimRight(u.Path, "/"), "/api")
+	return strings.TrimRight(u.String(), "/")
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tower
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// A stand-in for the Seqera Platform API
+func newServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/workflow", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("search") != "hello-abc" {
+			w.Write([]byte(`{"workflows": []}`))
+			return
+		}
+		w.Write([]byte(`{"workflows": [
+			{"workflow": {"id": "other", "runName": "hello-abcdef"}},
+			{"workflow": {"id": "4Bi5xBK6E2Nbhj", "runName": "hello-abc"}}
+		]}`))
+	})
+	mux.HandleFunc("/api/user-info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user": {"id": 7, "userName": "jdoe"}}`))
+	})
+	mux.HandleFunc("/api/user/7/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"orgsAndWorkspaces": [
+			{"orgName": "mnm", "workspaceId": null},
+			{"orgName": "mnm", "workspaceId": 1234, "workspaceName": "production"}
+		]}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFindRun(t *testing.T) {
+	server := newServer(t)
+	client := NewClient(server.URL+"/api/", "secret-token")
+
+	run, err := client.FindRun(context.Background(), "1234", "hello-abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ID != "4Bi5xBK6E2Nbhj" {
+		t.Errorf("unexpected run ID %q", run.ID)
+	}
+	if want := server.URL + "/orgs/mnm/workspaces/production/watch/4Bi5xBK6E2Nbhj"; run.URL != want {
+		t.Errorf("run URL is %q, want %q", run.URL, want)
+	}
+
+	run, err = client.FindRun(context.Background(), "", "hello-abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := server.URL + "/user/jdoe/watch/4Bi5xBK6E2Nbhj"; run.URL != want {
+		t.Errorf("run URL is %q, want %q", run.URL, want)
+	}
+}
+
+func TestFindRunErrors(t *testing.T) {
+	server := newServer(t)
+
+	_, err := NewClient(server.URL+"/api", "secret-token").FindRun(context.Background(), "", "missing")
+	if err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	_, err = NewClient(server.URL+"/api", "wrong-token").FindRun(context.Background(), "", "hello-abc")
+	if err == nil {
+		t.Error("request with a wrong token succeeded")
+	}
+}
+
+func TestWebURL(t *testing.T) {
+	cases := map[string]string{
+		"https://api.cloud.seqera.io":   "https://cloud.seqera.io",
+		"https://tower.example.com/api": "https://tower.example.com",
+		"http://localhost:8000":         "http://localhost:8000",
+	}
+	for endpoint, want := range cases {
+		if got := NewClient(endpoint, "").webURL(); got != want {
+			t.Errorf("webURL(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
@@ -52,11 +52,23 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var maxQueueSize int
+	var maxSubmitRate float64
+	var restrictedPodSecurity bool
+	var pluginsIndexURL string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&maxQueueSize, "max-queue-size", 1000,
+		"The maximum executor.queueSize of a launch (0 means no limit).")
+	flag.Float64Var(&maxSubmitRate, "max-submit-rate", 0,
+		"The maximum executor.submitRateLimit of a launch, in tasks per second (0 means no limit).")
+	flag.BoolVar(&restrictedPodSecurity, "restricted-pod-security", false,
+		"Apply the restricted Pod Security Standard to launches without a security section.")
+	flag.StringVar(&pluginsIndexURL, "plugins-index-url", "",
+		"The Nextflow plugin registry pinned plugins are checked against (default: the public registry).")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -81,10 +93,23 @@ func main() {
 	if err = (&controllers.NextflowLaunchReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		ExecutorLimits: controllers.ExecutorLimits{
+			MaxQueueSize:  int32(maxQueueSize),
+			MaxSubmitRate: maxSubmitRate,
+		},
+		RestrictedPodSecurity: restrictedPodSecurity,
+		PluginsIndexURL:       pluginsIndexURL,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NextflowLaunch")
 		os.Exit(1)
 	}
+	if err = (&controllers.NextflowLaunchSetReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NextflowLaunchSet")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {